@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+)
+
+// Runs queries and statements, implemented by *sql.DB, *sql.Conn and *sql.Tx. The In variants of the query
+// functions take one, so the same scanning code runs inside a transaction, e.g.
+//
+//	d, err := db.New(cfg)
+//	tx, err := d.DB(false).BeginTx(ctx, nil)
+//	user, err := db.OneIn[User](ctx, tx, "SELECT * FROM users WHERE id = ? FOR UPDATE", []interface{}{id})
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+type connKey struct{}
+
+// Pins a single connection from the write pool to the returned context. Every context-aware
+// query run with that context uses the pinned connection, so connection-scoped state such as
+// LAST_INSERT_ID() and session variables (@var) is shared between them.
+//
+// The returned function releases the connection back to the pool and MUST be called once done.
+// Calling WithConnectionAffinity on an already pinned context reuses its connection.
+// On error ctx is returned unchanged with a no-op release.
+func WithConnectionAffinity(ctx context.Context) (context.Context, func(), error) {
+	if _, ok := pinnedConn(ctx); ok {
+		return ctx, func() {}, nil
+	}
+
+	pool, err := getPool(false)
+	if err != nil {
+		return ctx, func() {}, err
+	}
+	conn, err := pool.Conn(ctx)
+	if err != nil {
+		return ctx, func() {}, err
+	}
+
+	var once sync.Once
+	return context.WithValue(ctx, connKey{}, conn), func() {
+		once.Do(func() { conn.Close() })
+	}, nil
+}
+
+func pinnedConn(ctx context.Context) (*sql.Conn, bool) {
+	conn, ok := ctx.Value(connKey{}).(*sql.Conn)
+	return conn, ok
+}
+
+// Returns the connection pinned to ctx, or the shared read (default) or write pool.
+// Reads use the write pool while ctx has reads left from UseWriteForNext.
+func dbFromContext(ctx context.Context, readOnly ...bool) (Queryer, error) {
+	return defaultDB.queryer(ctx, len(readOnly) == 0 || readOnly[0])
+}
+
+type writeReadsKey struct{}
+
+// Makes the next n reads made with the returned context (or contexts derived from it) use the write pool,
+// e.g. to read back a row that was just written and may not have reached the replicas yet.
+func UseWriteForNext(ctx context.Context, n int) context.Context {
+	left := new(atomic.Int64)
+	left.Store(int64(n))
+	return context.WithValue(ctx, writeReadsKey{}, left)
+}
+
+// Consumes one of the write pool reads granted to ctx by UseWriteForNext, if any are left.
+func takeWriteRead(ctx context.Context) bool {
+	left, ok := ctx.Value(writeReadsKey{}).(*atomic.Int64)
+	if !ok {
+		return false
+	}
+
+	for {
+		n := left.Load()
+		if n <= 0 {
+			return false
+		}
+		if left.CompareAndSwap(n, n-1) {
+			return true
+		}
+	}
+}
+
+// Runs fn on a dedicated connection from the write pool, returned to the pool once fn returns.
+// Connection-scoped state such as user-defined variables (@var) is shared by every statement fn runs,
+// e.g. `SET @rank := 0` followed by `SELECT @rank := @rank + 1 AS rank, name FROM users`.
+func WithStatement(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	pool, err := getPool(false)
+	if err != nil {
+		return err
+	}
+	conn, err := pool.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return fn(conn)
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWithConnectionAffinityReturnsErrors(t *testing.T) {
+	useSharedPool(t, openFakeDB(nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	pinned, release, err := WithConnectionAffinity(ctx)
+	defer release()
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+	if _, ok := pinnedConn(pinned); ok {
+		t.Error("a connection was pinned despite the error")
+	}
+
+	pinned, release, err = WithConnectionAffinity(context.Background())
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	defer release()
+	if _, ok := pinnedConn(pinned); !ok {
+		t.Error("no connection pinned")
+	}
+}
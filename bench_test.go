@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+// Rows scanned per benchmark operation.
+const benchRows = 100
+
+type benchRow struct {
+	ID        int64     `db:"id"`
+	Name      string    `db:"name"`
+	Email     string    `db:"email"`
+	Age       int       `db:"age"`
+	Score     float64   `db:"score"`
+	Active    bool      `db:"active"`
+	CreatedAt time.Time `db:"created_at"`
+	Note      *string   `db:"note"`
+}
+
+// Opens a fake pool returning benchRows rows of benchRow.
+func openBenchDB(b *testing.B) *sql.DB {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := make([][]driver.Value, benchRows)
+	for i := range rows {
+		rows[i] = []driver.Value{int64(i), []byte("name"), []byte("user@example.com"), int64(30), 1.5, true, created, nil}
+	}
+
+	pool := openFakeDB([]string{"id", "name", "email", "age", "score", "active", "created_at", "note"}, rows...)
+	b.Cleanup(func() { pool.Close() })
+	return pool
+}
+
+// Runs the query of every benchmark operation and passes its rows to scan.
+func benchScan(b *testing.B, scan func(rows *sql.Rows) error) {
+	pool := openBenchDB(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rows, err := pool.Query("SELECT * FROM users")
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := scan(rows); err != nil {
+			b.Fatal(err)
+		}
+		rows.Close()
+	}
+}
+
+// The column mapping and scan buffers built for every row, as before StructScanner.
+func BenchmarkScanPerRowMapping(b *testing.B) {
+	benchScan(b, func(rows *sql.Rows) error {
+		for rows.Next() {
+			if _, err := NewStructScanner[benchRow]().Scan(rows); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	})
+}
+
+// One StructScanner reused for every row of the result set.
+func BenchmarkScanStructScanner(b *testing.B) {
+	benchScan(b, func(rows *sql.Rows) error {
+		scanner := NewStructScanner[benchRow]()
+		for rows.Next() {
+			if _, err := scanner.Scan(rows); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	})
+}
+
+// ScanStruct called row by row, reusing pooled scanners.
+func BenchmarkScanStructPooled(b *testing.B) {
+	benchScan(b, func(rows *sql.Rows) error {
+		for rows.Next() {
+			if _, err := scanStruct[benchRow](rows); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	})
+}
+
+// Reads a single row through the shared pools.
+func benchOne(b *testing.B, one func() error) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	pool := openFakeDB([]string{"id", "name", "email", "age", "score", "active", "created_at", "note"},
+		[]driver.Value{int64(1), []byte("name"), []byte("user@example.com"), int64(30), 1.5, true, created, nil})
+	useSharedPool(b, pool)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := one(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkOne(b *testing.B) {
+	benchOne(b, func() error {
+		_, err := One[benchRow]("SELECT * FROM users WHERE id = ?", []interface{}{1})
+		return err
+	})
+}
+
+func BenchmarkQueryOne(b *testing.B) {
+	ctx := context.Background()
+	benchOne(b, func() error {
+		_, _, err := QueryOne[benchRow](ctx, "SELECT * FROM users WHERE id = ?", []interface{}{1})
+		return err
+	})
+}
@@ -0,0 +1,366 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Builds SELECT (and INSERT ... SELECT) statements fluently. Identifiers are validated and quoted and values are always
+// bound as placeholders, so the structured methods are safe to use with dynamic input.
+// The Raw* methods are escape hatches for SQL the structured methods cannot express.
+type QueryBuilder struct {
+	insertTable string
+	insertCols  []string
+	columns     []string
+	table       string
+	joins       []sqlPart
+	where       []sqlPart
+	groupBy     []string
+	having      []sqlPart
+	orderBy     []string
+	limit       int
+	hasLimit    bool
+	offset      int
+	err         error
+}
+
+// A SQL fragment and the arguments bound to its placeholders.
+type sqlPart struct {
+	sql  string
+	args []interface{}
+}
+
+var identRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+
+func NewQueryBuilder() *QueryBuilder {
+	return &QueryBuilder{}
+}
+
+// Starts a SELECT from table, e.g.
+//
+//	users, err := db.AllBuilder[User](ctx, db.Select("users").Where("status = ?", status).OrderBy("created_at DESC").Limit(20))
+func Select(table string) *QueryBuilder {
+	return NewQueryBuilder().From(table)
+}
+
+// Turns the statement into `INSERT INTO table (cols...) SELECT ...`, the fluent counterpart of InsertSelect.
+// The SELECT list must have one column per destination column.
+func (qb *QueryBuilder) InsertInto(table string, cols ...string) *QueryBuilder {
+	if !qb.checkIdent(table) {
+		return qb
+	}
+	if len(cols) == 0 {
+		qb.fail(errors.New("db: no destination columns"))
+		return qb
+	}
+
+	qb.insertTable = quoteIdent(table)
+	for _, col := range cols {
+		if !qb.checkIdent(col) {
+			return qb
+		}
+		qb.insertCols = append(qb.insertCols, quoteIdent(col))
+	}
+	return qb
+}
+
+// Adds columns to the SELECT list. Each argument may also be a comma-separated list of columns,
+// such as the output of SelectFields.
+func (qb *QueryBuilder) Select(cols ...string) *QueryBuilder {
+	for _, list := range cols {
+		for _, col := range strings.Split(list, ",") {
+			col = strings.ReplaceAll(strings.TrimSpace(col), "`", "")
+			if col == "*" || (strings.HasSuffix(col, ".*") && identRegex.MatchString(strings.TrimSuffix(col, ".*"))) {
+				qb.columns = append(qb.columns, col)
+				continue
+			}
+
+			if !qb.checkIdent(col) {
+				return qb
+			}
+			qb.columns = append(qb.columns, quoteIdent(col))
+		}
+	}
+	return qb
+}
+
+// Adds validated column expressions to the SELECT list, e.g. SelectExprs(ColTable("o", "id"), ColFunc("COUNT", Col("*")).As("n")).
+func (qb *QueryBuilder) SelectExprs(exprs ...ColExpr) *QueryBuilder {
+	for _, e := range exprs {
+		if e.err != nil {
+			qb.fail(e.err)
+			return qb
+		}
+		qb.columns = append(qb.columns, e.sql)
+	}
+	return qb
+}
+
+// Appends sql verbatim to the SELECT list, e.g. `COUNT(*) AS total`.
+//
+// UNSAFE: sql is not validated in any way, the caller MUST make sure it never contains user input.
+func (qb *QueryBuilder) RawSelect(sql string) *QueryBuilder {
+	qb.columns = append(qb.columns, sql)
+	return qb
+}
+
+// Sets the table to select from, optionally followed by an alias, e.g. From("users u").
+func (qb *QueryBuilder) From(table string) *QueryBuilder {
+	if ref, ok := qb.tableRef(table); ok {
+		qb.table = ref
+	}
+	return qb
+}
+
+// Adds an INNER JOIN of table, optionally followed by an alias, on a condition validated like Where,
+// e.g. Join("orders o", "o.user_id = u.id AND o.status = ?", status).
+func (qb *QueryBuilder) Join(table, on string, args ...interface{}) *QueryBuilder {
+	return qb.join("JOIN", table, on, args)
+}
+
+// Same as Join with a LEFT JOIN.
+func (qb *QueryBuilder) LeftJoin(table, on string, args ...interface{}) *QueryBuilder {
+	return qb.join("LEFT JOIN", table, on, args)
+}
+
+func (qb *QueryBuilder) join(kind, table, on string, args []interface{}) *QueryBuilder {
+	ref, ok := qb.tableRef(table)
+	if ok && qb.checkCondition(on, args) {
+		qb.joins = append(qb.joins, sqlPart{sql: fmt.Sprintf(" %s %s ON %s", kind, ref, on), args: args})
+	}
+	return qb
+}
+
+// Adds a condition joined with AND, e.g. Where("status = ?", status).
+// The condition must not contain literals, comments or statement separators: pass every value as an argument.
+func (qb *QueryBuilder) Where(cond string, args ...interface{}) *QueryBuilder {
+	if qb.checkCondition(cond, args) {
+		qb.where = append(qb.where, sqlPart{sql: cond, args: args})
+	}
+	return qb
+}
+
+// Adds a condition joined with AND without any validation, e.g. a subquery or function call.
+//
+// UNSAFE: sql is used verbatim, the caller MUST make sure it never contains user input.
+// Values should still be passed as args.
+func (qb *QueryBuilder) RawWhere(sql string, args ...interface{}) *QueryBuilder {
+	qb.where = append(qb.where, sqlPart{sql: sql, args: args})
+	return qb
+}
+
+func (qb *QueryBuilder) GroupBy(cols ...string) *QueryBuilder {
+	for _, col := range cols {
+		if qb.checkIdent(col) {
+			qb.groupBy = append(qb.groupBy, quoteIdent(col))
+		}
+	}
+	return qb
+}
+
+// Adds a HAVING condition joined with AND, validated like Where.
+func (qb *QueryBuilder) Having(cond string, args ...interface{}) *QueryBuilder {
+	if qb.checkCondition(cond, args) {
+		qb.having = append(qb.having, sqlPart{sql: cond, args: args})
+	}
+	return qb
+}
+
+// Adds a HAVING condition joined with AND without any validation.
+//
+// UNSAFE: sql is used verbatim, the caller MUST make sure it never contains user input.
+func (qb *QueryBuilder) RawHaving(sql string, args ...interface{}) *QueryBuilder {
+	qb.having = append(qb.having, sqlPart{sql: sql, args: args})
+	return qb
+}
+
+// Adds sort keys, each a column optionally followed by ASC or DESC, e.g. OrderBy("created_at DESC", "id").
+// Each argument may also be a comma-separated list of them.
+func (qb *QueryBuilder) OrderBy(keys ...string) *QueryBuilder {
+	for _, list := range keys {
+		for _, key := range strings.Split(list, ",") {
+			col, dir, _ := strings.Cut(strings.TrimSpace(key), " ")
+			dir = strings.ToUpper(strings.TrimSpace(dir))
+			if dir != "" && dir != "ASC" && dir != "DESC" {
+				qb.fail(fmt.Errorf("db: invalid sort direction %q", dir))
+				return qb
+			}
+			if !qb.checkIdent(col) {
+				return qb
+			}
+
+			if dir != "" {
+				qb.orderBy = append(qb.orderBy, quoteIdent(col)+" "+dir)
+			} else {
+				qb.orderBy = append(qb.orderBy, quoteIdent(col))
+			}
+		}
+	}
+	return qb
+}
+
+func (qb *QueryBuilder) Limit(n int) *QueryBuilder {
+	if n < 0 {
+		qb.fail(fmt.Errorf("db: invalid limit %d", n))
+		return qb
+	}
+	qb.limit, qb.hasLimit = n, true
+	return qb
+}
+
+func (qb *QueryBuilder) Offset(n int) *QueryBuilder {
+	if n < 0 {
+		qb.fail(fmt.Errorf("db: invalid offset %d", n))
+		return qb
+	}
+	qb.offset = n
+	return qb
+}
+
+// Renders the statement and its arguments, or the first error recorded while building it.
+func (qb *QueryBuilder) Build() (string, []interface{}, error) {
+	if qb.err != nil {
+		return "", nil, qb.err
+	}
+	if qb.table == "" {
+		return "", nil, errors.New("db: query builder has no table")
+	}
+
+	var (
+		b    strings.Builder
+		args []interface{}
+	)
+
+	if qb.insertTable != "" {
+		if len(qb.columns) != len(qb.insertCols) {
+			return "", nil, fmt.Errorf("db: %d selected columns for %d destination columns", len(qb.columns), len(qb.insertCols))
+		}
+		fmt.Fprintf(&b, "INSERT INTO %s (%s) ", qb.insertTable, strings.Join(qb.insertCols, ", "))
+	}
+
+	cols := "*"
+	if len(qb.columns) > 0 {
+		cols = strings.Join(qb.columns, ", ")
+	}
+	fmt.Fprintf(&b, "SELECT %s FROM %s", cols, qb.table)
+	for _, join := range qb.joins {
+		b.WriteString(join.sql)
+		args = append(args, join.args...)
+	}
+
+	if len(qb.where) > 0 {
+		b.WriteString(" WHERE ")
+		args = append(args, joinParts(&b, qb.where)...)
+	}
+	if len(qb.groupBy) > 0 {
+		b.WriteString(" GROUP BY " + strings.Join(qb.groupBy, ", "))
+	}
+	if len(qb.having) > 0 {
+		b.WriteString(" HAVING ")
+		args = append(args, joinParts(&b, qb.having)...)
+	}
+	if len(qb.orderBy) > 0 {
+		b.WriteString(" ORDER BY " + strings.Join(qb.orderBy, ", "))
+	}
+	switch {
+	case qb.hasLimit:
+		b.WriteString(" LIMIT ?")
+		args = append(args, qb.limit)
+		if qb.offset > 0 {
+			b.WriteString(" OFFSET ?")
+			args = append(args, qb.offset)
+		}
+	case qb.offset > 0:
+		// MySQL has no OFFSET without LIMIT, use the largest row count
+		b.WriteString(" LIMIT 18446744073709551615 OFFSET ?")
+		args = append(args, qb.offset)
+	}
+
+	query, err := SanitizeSQL(b.String())
+	if err != nil {
+		return "", nil, err
+	}
+	return query, args, nil
+}
+
+// Runs the query built by qb like AllCtx.
+func AllBuilder[T any](ctx context.Context, qb *QueryBuilder) ([]T, error) {
+	query, args, err := qb.Build()
+	if err != nil {
+		return nil, err
+	}
+	return allContext[T](ctx, query, args)
+}
+
+// Runs the query built by qb like OneCtx.
+func OneBuilder[T any](ctx context.Context, qb *QueryBuilder) (*T, error) {
+	query, args, err := qb.Build()
+	if err != nil {
+		return nil, err
+	}
+	return OneCtx[T](ctx, query, args)
+}
+
+// Writes the parts joined with AND, each wrapped in parentheses when there is more than one.
+func joinParts(b *strings.Builder, parts []sqlPart) []interface{} {
+	var args []interface{}
+	for i, part := range parts {
+		if i > 0 {
+			b.WriteString(" AND ")
+		}
+		if len(parts) > 1 {
+			b.WriteString("(" + part.sql + ")")
+		} else {
+			b.WriteString(part.sql)
+		}
+		args = append(args, part.args...)
+	}
+	return args
+}
+
+// Validates and quotes a table name optionally followed by an alias, with or without AS.
+func (qb *QueryBuilder) tableRef(table string) (string, bool) {
+	fields := strings.Fields(table)
+	if len(fields) == 3 && strings.EqualFold(fields[1], "AS") {
+		fields = []string{fields[0], fields[2]}
+	}
+
+	switch {
+	case len(fields) == 1 && qb.checkIdent(fields[0]):
+		return quoteIdent(fields[0]), true
+	case len(fields) == 2 && qb.checkIdent(fields[0]) && qb.checkIdent(fields[1]) && !strings.Contains(fields[1], "."):
+		return quoteIdent(fields[0]) + " AS " + quoteIdent(fields[1]), true
+	case len(fields) != 1 && len(fields) != 2:
+		qb.fail(fmt.Errorf("db: invalid table %q", table))
+	}
+	return "", false
+}
+
+func (qb *QueryBuilder) checkIdent(name string) bool {
+	if !identRegex.MatchString(name) {
+		qb.fail(fmt.Errorf("db: invalid identifier %q", name))
+		return false
+	}
+	return true
+}
+
+func (qb *QueryBuilder) checkCondition(cond string, args []interface{}) bool {
+	if strings.ContainsAny(cond, `'";#`) || strings.Contains(cond, "--") || strings.Contains(cond, "/*") {
+		qb.fail(fmt.Errorf("db: condition %q must not contain literals or comments, use placeholders", cond))
+		return false
+	}
+	if n := strings.Count(cond, "?"); n != len(args) {
+		qb.fail(fmt.Errorf("db: condition %q has %d placeholders but %d args", cond, n, len(args)))
+		return false
+	}
+	return true
+}
+
+func (qb *QueryBuilder) fail(err error) {
+	if qb.err == nil {
+		qb.err = err
+	}
+}
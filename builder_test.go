@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQueryBuilderBuild(t *testing.T) {
+	type user struct {
+		ID       int64
+		Name     string
+		Password string
+	}
+
+	tests := []struct {
+		name     string
+		qb       *QueryBuilder
+		want     string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "select",
+			qb:       Select("users").Where("status = ?", "active").OrderBy("created_at DESC, id").Limit(20),
+			want:     "SELECT * FROM `users` WHERE status = ? ORDER BY `created_at` DESC, `id` LIMIT ?",
+			wantArgs: []interface{}{"active", 20},
+		},
+		{
+			name: "joins",
+			qb: Select("users u").SelectExprs(ColTable("u", "id"), ColFunc("COUNT", ColTable("o", "id")).As("orders")).
+				Join("orders AS o", "o.user_id = u.id AND o.status = ?", "paid").
+				LeftJoin("profiles p", "p.user_id = u.id").
+				Where("u.country = ?", "FR").GroupBy("u.id").Having("COUNT(o.id) > ?", 2),
+			want: "SELECT `u`.`id`, COUNT(`o`.`id`) AS `orders` FROM `users` AS `u` JOIN `orders` AS `o` ON o.user_id = u.id AND o.status = ? " +
+				"LEFT JOIN `profiles` AS `p` ON p.user_id = u.id WHERE u.country = ? GROUP BY `u`.`id` HAVING COUNT(o.id) > ?",
+			wantArgs: []interface{}{"paid", "FR", 2},
+		},
+		{
+			name:     "nested where groups",
+			qb:       Select("users").Where("a = ? OR (b = ? AND c = ?)", 1, 2, 3).Where("d = ?", 4).RawWhere("EXISTS (SELECT 1 FROM bans WHERE bans.user_id = users.id AND bans.until > NOW())"),
+			want:     "SELECT * FROM `users` WHERE (a = ? OR (b = ? AND c = ?)) AND (d = ?) AND (EXISTS (SELECT 1 FROM bans WHERE bans.user_id = users.id AND bans.until > NOW()))",
+			wantArgs: []interface{}{1, 2, 3, 4},
+		},
+		{
+			name:     "in",
+			qb:       Select("users").Where("id IN (?)", []int64{1, 2, 3}),
+			want:     "SELECT * FROM `users` WHERE id IN (?)",
+			wantArgs: []interface{}{[]int64{1, 2, 3}},
+		},
+		{
+			name:     "limit and offset",
+			qb:       Select("users").Select(SelectFields[user]("Password")).Limit(10).Offset(30),
+			want:     "SELECT `id`, `name` FROM `users` LIMIT ? OFFSET ?",
+			wantArgs: []interface{}{10, 30},
+		},
+		{
+			name:     "offset without limit",
+			qb:       Select("users").Offset(30),
+			want:     "SELECT * FROM `users` LIMIT 18446744073709551615 OFFSET ?",
+			wantArgs: []interface{}{30},
+		},
+		{
+			name:     "insert select",
+			qb:       NewQueryBuilder().InsertInto("archive", "id", "name").Select("id", "name").From("users").Where("status = ?", "deleted"),
+			want:     "INSERT INTO `archive` (`id`, `name`) SELECT `id`, `name` FROM `users` WHERE status = ?",
+			wantArgs: []interface{}{"deleted"},
+		},
+		{
+			name: "raw select",
+			qb:   Select("users").RawSelect("COUNT(*) AS total").RawHaving("total > 1"),
+			want: "SELECT COUNT(*) AS total FROM `users` HAVING total > 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args, err := tt.qb.Build()
+			if err != nil {
+				t.Fatalf("Build error = %v", err)
+			}
+			if got != tt.want || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Build =\n%q %v\nwant\n%q %v", got, args, tt.want, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestQueryBuilderExpandsSlicesWhenRun(t *testing.T) {
+	pool, fake := openFakeConnector([]string{"id"})
+	useSharedPool(t, pool)
+
+	var queries []string
+	fake.queryErr = func(query string) error {
+		queries = append(queries, query)
+		return nil
+	}
+
+	if _, err := AllBuilder[struct{ ID int64 }](context.Background(), Select("users").Where("id IN (?)", []int64{1, 2, 3})); err != nil {
+		t.Fatalf("AllBuilder error = %v", err)
+	}
+	if len(queries) != 1 || !strings.HasSuffix(queries[0], "WHERE id IN (?, ?, ?)") {
+		t.Errorf("queries = %q, want the IN list expanded", queries)
+	}
+}
+
+func TestQueryBuilderErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		qb   *QueryBuilder
+		want string
+	}{
+		{"no table", NewQueryBuilder().Where("id = ?", 1), "no table"},
+		{"invalid table", Select("users; DROP TABLE users"), "invalid"},
+		{"invalid join", Select("users").Join("orders o x", "o.id = users.id"), "invalid table"},
+		{"literal in condition", Select("users").Where("name = 'bob'"), "must not contain literals"},
+		{"comment in condition", Select("users").Where("id = ? -- x", 1), "must not contain literals"},
+		{"comment in join", Select("users u").Join("orders o", "o.user_id = u.id /* x */"), "must not contain literals"},
+		{"placeholder count", Select("users").Where("id = ? AND status = ?", 1), "2 placeholders but 1 args"},
+		{"sort direction", Select("users").OrderBy("id SIDEWAYS"), "invalid sort direction"},
+		{"column", Select("users").Select("id, name)"), "invalid identifier"},
+		{"limit", Select("users").Limit(-1), "invalid limit"},
+		{"offset", Select("users").Offset(-1), "invalid offset"},
+		{"insert columns", NewQueryBuilder().InsertInto("archive", "id").Select("id", "name").From("users"), "2 selected columns for 1"},
+		{"first error wins", Select("users").Limit(-1).Offset(-1), "invalid limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := tt.qb.Build(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Build error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A row-level change published after a successful write.
+type ChangeEvent struct {
+	Table     string
+	Operation string // INSERT, REPLACE, UPSERT, UPDATE or DELETE
+	RowData   interface{}
+	Timestamp time.Time
+}
+
+var (
+	changeMu        sync.RWMutex
+	changeListeners []*changeListener
+
+	// INSERT/REPLACE [modifiers] [INTO] table, UPDATE [modifiers] table, DELETE [modifiers] FROM table
+	changeRegex = regexp.MustCompile("(?is)^\\s*(?:(INSERT|REPLACE)\\s+(?:(?:LOW_PRIORITY|DELAYED|HIGH_PRIORITY|IGNORE)\\s+)*(?:INTO\\s+)?|(UPDATE)\\s+(?:(?:LOW_PRIORITY|IGNORE)\\s+)*|(DELETE)\\s+(?:(?:LOW_PRIORITY|QUICK|IGNORE)\\s+)*FROM\\s+)([\\w$.`]+)")
+)
+
+// Registers a listener called synchronously after every successful write made through Exec,
+// MultiExecReport or the struct helpers such as Replace.
+//
+// For struct helpers RowData is the struct that was written, for raw statements it is the argument slice.
+// The database is never re-queried.
+//
+// The returned function removes the listener; calling it again does nothing.
+func OnChange(fn func(ChangeEvent)) func() {
+	listener := &changeListener{fn: fn}
+
+	changeMu.Lock()
+	defer changeMu.Unlock()
+
+	changeListeners = append(changeListeners, listener)
+	return func() { removeChangeListener(listener) }
+}
+
+// Wraps a listener so each registration has its own identity, even for the same func.
+type changeListener struct {
+	fn func(ChangeEvent)
+}
+
+func removeChangeListener(listener *changeListener) {
+	changeMu.Lock()
+	defer changeMu.Unlock()
+
+	for i, l := range changeListeners {
+		if l == listener {
+			changeListeners = append(changeListeners[:i:i], changeListeners[i+1:]...)
+			return
+		}
+	}
+}
+
+func publishChange(table string, operation string, row interface{}) {
+	if dryRun.Load() {
+		return
+	}
+
+	changeMu.RLock()
+	listeners := changeListeners
+	changeMu.RUnlock()
+
+	if len(listeners) == 0 {
+		return
+	}
+
+	event := ChangeEvent{
+		Table:     strings.ReplaceAll(table, "`", ""),
+		Operation: operation,
+		RowData:   row,
+		Timestamp: time.Now(),
+	}
+	for _, listener := range listeners {
+		listener.fn(event)
+	}
+}
+
+// Publishes the change made by a raw INSERT, REPLACE, UPDATE or DELETE statement. Other statements are ignored.
+func publishQueryChange(query string, args []interface{}) {
+	m := changeRegex.FindStringSubmatch(query)
+	if m == nil {
+		return
+	}
+
+	publishChange(m[4], strings.ToUpper(m[1]+m[2]+m[3]), args)
+}
@@ -0,0 +1,23 @@
+package db
+
+import "testing"
+
+func TestOnChangeRemovesOnlyItsListener(t *testing.T) {
+	counts := make([]int, 2)
+	listen := func(i int) func(ChangeEvent) {
+		return func(ChangeEvent) { counts[i]++ }
+	}
+
+	off0 := OnChange(listen(0))
+	off1 := OnChange(listen(1))
+	defer off1()
+
+	publishChange("users", "INSERT", nil)
+	off0()
+	off0()
+	publishChange("users", "INSERT", nil)
+
+	if counts[0] != 1 || counts[1] != 2 {
+		t.Errorf("calls = %v, want [1 2]", counts)
+	}
+}
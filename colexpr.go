@@ -0,0 +1,107 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// A validated, quoted SELECT list expression: a column, a table column, a function call or an alias of one.
+type ColExpr struct {
+	sql string
+	err error
+}
+
+var funcNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// A column, e.g. Col("price") or Col("*").
+func Col(name string) ColExpr {
+	if name == "*" {
+		return ColExpr{sql: "*"}
+	}
+	if !identRegex.MatchString(name) {
+		return ColExpr{err: fmt.Errorf("db: invalid identifier %q", name)}
+	}
+	return ColExpr{sql: quoteIdent(name)}
+}
+
+// A column renamed in the result, e.g. ColAlias("price", "unit_price").
+func ColAlias(name, alias string) ColExpr {
+	return Col(name).As(alias)
+}
+
+// A column of a given table or alias, e.g. ColTable("o", "id") or ColTable("o", "*").
+func ColTable(table, name string) ColExpr {
+	if !identRegex.MatchString(table) || strings.Contains(table, ".") {
+		return ColExpr{err: fmt.Errorf("db: invalid identifier %q", table)}
+	}
+	if name == "*" {
+		return ColExpr{sql: quoteIdent(table) + ".*"}
+	}
+	if !identRegex.MatchString(name) || strings.Contains(name, ".") {
+		return ColExpr{err: fmt.Errorf("db: invalid identifier %q", name)}
+	}
+	return ColExpr{sql: quoteIdent(table) + "." + quoteIdent(name)}
+}
+
+// A function call, e.g. ColFunc("COUNT", Col("*")) or ColFunc("MAX", Col("price")).As("max_price").
+func ColFunc(fn string, args ...ColExpr) ColExpr {
+	if !funcNameRegex.MatchString(fn) {
+		return ColExpr{err: fmt.Errorf("db: invalid function name %q", fn)}
+	}
+
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		if arg.err != nil {
+			return arg
+		}
+		parts[i] = arg.sql
+	}
+	return ColExpr{sql: strings.ToUpper(fn) + "(" + strings.Join(parts, ", ") + ")"}
+}
+
+// Renames the expression in the result.
+func (e ColExpr) As(alias string) ColExpr {
+	if e.err != nil {
+		return e
+	}
+	if !identRegex.MatchString(alias) || strings.Contains(alias, ".") {
+		return ColExpr{err: fmt.Errorf("db: invalid alias %q", alias)}
+	}
+	return ColExpr{sql: e.sql + " AS " + quoteIdent(alias)}
+}
+
+// A SELECT list built from ColExpr values.
+type ColumnList []ColExpr
+
+func ColList(exprs ...ColExpr) ColumnList {
+	return ColumnList(exprs)
+}
+
+// Returns the first invalid identifier or function name of the list.
+func (l ColumnList) Err() error {
+	if len(l) == 0 {
+		return errors.New("db: empty column list")
+	}
+	for _, e := range l {
+		if e.err != nil {
+			return e.err
+		}
+	}
+	return nil
+}
+
+// Returns the comma-separated list, e.g. "`o`.`id`, MAX(`price`) AS `max_price`",
+// or an empty string when Err reports an invalid expression.
+func (l ColumnList) ToSQL() string {
+	if l.Err() != nil {
+		return ""
+	}
+
+	parts := make([]string, len(l))
+	for i, e := range l {
+		parts[i] = e.sql
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Executes the query and groups the rows by keyFn, keeping the query order within each group.
+func AllGrouped[K comparable, T any](query string, args []interface{}, keyFn func(T) K) (map[K][]T, error) {
+	rows, err := allContext[T](context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[K][]T)
+	for _, row := range rows {
+		key := keyFn(row)
+		groups[key] = append(groups[key], row)
+	}
+	return groups, nil
+}
+
+// Executes the query and keeps only the first row for each key returned by keyFn, preserving the query order.
+// Useful when the deduplication rule cannot be expressed with SELECT DISTINCT.
+func AllDistinctOn[T any, K comparable](query string, args []interface{}, keyFn func(T) K) ([]T, error) {
+	rows, err := allContext[T](context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[K]struct{}, len(rows))
+	res := rows[:0]
+	for _, row := range rows {
+		key := keyFn(row)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		res = append(res, row)
+	}
+	return res, nil
+}
+
+// Executes the query and keeps only the rows for which filter returns true.
+// The filter runs after scanning, so it may use methods of T.
+func AllFiltered[T any](ctx context.Context, query string, args []interface{}, filter func(T) bool) ([]T, error) {
+	var res []T
+	err := ForEach(ctx, query, args, func(row T) error {
+		if filter(row) {
+			res = append(res, row)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// Executes the query, maps each row with fn and keeps the results for which fn returns true.
+func AllTransformed[T, U any](ctx context.Context, query string, args []interface{}, fn func(T) (U, bool)) ([]U, error) {
+	var res []U
+	err := ForEach(ctx, query, args, func(row T) error {
+		if v, ok := fn(row); ok {
+			res = append(res, v)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// Scans every remaining row of rows straight into a map keyed by keyFn, without building a slice first.
+// A later row with the same key replaces the earlier one. The caller still owns rows and must close it.
+func ScanToMap[K comparable, T any](rows *sql.Rows, keyFn func(T) K) (map[K]T, error) {
+	res := make(map[K]T)
+	scanner := newRowScanner[T]()
+	for rows.Next() {
+		row, err := scanner.Scan(rows)
+		if err != nil {
+			return res, ClassifyError(err)
+		}
+		res[keyFn(row)] = row
+	}
+	return res, ClassifyError(rows.Err())
+}
+
+// Describes a result column as reported by the driver.
+type ColumnMeta struct {
+	Name             string
+	DatabaseTypeName string // e.g. "VARCHAR", "BIGINT", "DATETIME"
+	Nullable         bool
+	Length           int64 // 0 when the driver does not report it, which is the case of go-sql-driver/mysql
+}
+
+// Same as All but also returns the metadata of the result columns, read from the same result set.
+func AllWithMetadata[T any](ctx context.Context, query string, args []interface{}) (rows []T, cols []ColumnMeta, err error) {
+	defer timer(query, args)()
+
+	db, err := dbFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := queryRows(ctx, db, query, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Close()
+
+	types, err := res.ColumnTypes()
+	if err != nil {
+		return nil, nil, err
+	}
+	cols = make([]ColumnMeta, len(types))
+	for i, ct := range types {
+		nullable, _ := ct.Nullable()
+		length, _ := ct.Length()
+		cols[i] = ColumnMeta{Name: ct.Name(), DatabaseTypeName: ct.DatabaseTypeName(), Nullable: nullable, Length: length}
+	}
+
+	rows, err = scanAll[T](res)
+	return rows, cols, err
+}
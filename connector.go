@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	connInitMu  sync.RWMutex
+	connInitSQL []string
+	onConnect   func(ctx context.Context, conn *sql.Conn) error
+)
+
+// Sets statements run on every new connection before the pool hands it out, e.g.
+//
+//	db.SetConnectionInitSQL("SET time_zone = '+00:00'", "SET SESSION group_concat_max_len = 1000000")
+//
+// This replaces per-query SET statements. Connections already in a pool are not affected,
+// so call it before the first query or reopen the pools with CloseDB.
+func SetConnectionInitSQL(statements ...string) {
+	connInitMu.Lock()
+	defer connInitMu.Unlock()
+
+	connInitSQL = append([]string(nil), statements...)
+}
+
+// Sets a function called on every new connection, after the SetConnectionInitSQL statements and before
+// the pool hands it out, e.g. to run session setup depending on the environment. An error discards the connection.
+// conn MUST NOT be used after fn returns. Pass nil to remove the hook.
+func SetOnConnect(fn func(ctx context.Context, conn *sql.Conn) error) {
+	connInitMu.Lock()
+	defer connInitMu.Unlock()
+
+	onConnect = fn
+}
+
+// Opens a pool for cfg whose new connections go through the connection initialisation.
+func openPool(cfg *mysql.Config) (*sql.DB, error) {
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(initConnector{connector}), nil
+}
+
+// Runs the connection init statements on every connection it creates.
+type initConnector struct {
+	driver.Connector
+}
+
+func (c initConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	connInitMu.RLock()
+	statements, hook := connInitSQL, onConnect
+	connInitMu.RUnlock()
+
+	if len(statements) > 0 {
+		execer, ok := conn.(driver.ExecerContext)
+		if !ok {
+			conn.Close()
+			return nil, fmt.Errorf("db: %T cannot run connection init statements", conn)
+		}
+
+		for _, stmt := range statements {
+			if _, err := execer.ExecContext(ctx, stmt, nil); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("db: connection init %q: %w", stmt, err)
+			}
+		}
+	}
+
+	if hook != nil {
+		if err := lendConn(ctx, conn, hook); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("db: on connect: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// Hands a driver connection to fn as a *sql.Conn, through a throwaway pool that never closes it.
+func lendConn(ctx context.Context, conn driver.Conn, fn func(ctx context.Context, conn *sql.Conn) error) error {
+	pool := sql.OpenDB(lentConnector{conn})
+	pool.SetMaxOpenConns(1)
+	defer pool.Close()
+
+	sqlConn, err := pool.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer sqlConn.Close()
+
+	return fn(ctx, sqlConn)
+}
+
+// Always returns the same connection, wrapped so the throwaway pool cannot close it.
+type lentConnector struct {
+	conn driver.Conn
+}
+
+func (c lentConnector) Connect(context.Context) (driver.Conn, error) {
+	return lentConn{c.conn}, nil
+}
+
+func (c lentConnector) Driver() driver.Driver {
+	return mysql.MySQLDriver{}
+}
+
+type lentConn struct {
+	driver.Conn
+}
+
+func (lentConn) Close() error {
+	return nil
+}
+
+func (c lentConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c lentConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := c.Conn.(driver.QueryerContext); ok {
+		return queryer.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
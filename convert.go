@@ -0,0 +1,117 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// Converts a typed slice to the []interface{} expected for query arguments.
+func ToInterface[T any](items []T) []interface{} {
+	res := make([]interface{}, len(items))
+	for i, item := range items {
+		res[i] = item
+	}
+	return res
+}
+
+// Converts []interface{} back to a typed slice, converting each item when needed as typeConvertor does,
+// except that an item that cannot be converted, such as "abc" for an int, is an error rather than 0.
+func FromInterface[T any](items []interface{}) ([]T, error) {
+	target := reflect.TypeOf((*T)(nil)).Elem()
+
+	res := make([]T, len(items))
+	for i, item := range items {
+		if v, ok := item.(T); ok {
+			res[i] = v
+			continue
+		}
+
+		value, err := convertChecked(item, target)
+		if err != nil {
+			return nil, fmt.Errorf("db: item %d: cannot convert %v to %s: %w", i, item, target, err)
+		}
+
+		converted := reflect.ValueOf(value)
+		switch {
+		case converted.IsValid() && converted.Type().AssignableTo(target):
+			reflect.ValueOf(&res[i]).Elem().Set(converted)
+		case converted.IsValid() && converted.Type().ConvertibleTo(target):
+			reflect.ValueOf(&res[i]).Elem().Set(converted.Convert(target))
+		default:
+			return nil, fmt.Errorf("db: item %d: cannot convert %T to %s", i, item, target)
+		}
+	}
+	return res, nil
+}
+
+// Converts value to the kind of t like typeConvertor, with the cast.ToXE functions so invalid values are
+// reported. Integers are parsed in base 10 and checked against the size of t.
+func convertChecked(value interface{}, t reflect.Type) (interface{}, error) {
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+
+	if fn, ok := converterFor(t); ok {
+		return fn(value)
+	}
+	if isEnumType(t) {
+		s, err := enumValue(t, value)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.ValueOf(s).Convert(t).Interface(), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return cast.ToBoolE(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var (
+			n   int64
+			err error
+		)
+		if s, ok := value.(string); ok {
+			n, err = strconv.ParseInt(s, 10, 64)
+		} else {
+			n, err = cast.ToInt64E(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if reflect.Zero(t).OverflowInt(n) {
+			return nil, fmt.Errorf("%d overflows %s", n, t)
+		}
+		return n, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var (
+			n   uint64
+			err error
+		)
+		if s, ok := value.(string); ok {
+			n, err = strconv.ParseUint(s, 10, 64)
+		} else {
+			n, err = cast.ToUint64E(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if reflect.Zero(t).OverflowUint(n) {
+			return nil, fmt.Errorf("%d overflows %s", n, t)
+		}
+		return n, nil
+	case reflect.Float32, reflect.Float64:
+		return cast.ToFloat64E(value)
+	case reflect.String:
+		return cast.ToStringE(value)
+	case reflect.Map:
+		return cast.ToStringMapE(value)
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return cast.ToTimeE(value)
+	}
+	return typeConvertor(value, t), nil
+}
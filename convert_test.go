@@ -0,0 +1,33 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFromInterface(t *testing.T) {
+	ints, err := FromInterface[int32]([]interface{}{int32(1), "2", []byte("3"), int64(4), 5.0})
+	if err != nil || !reflect.DeepEqual(ints, []int32{1, 2, 3, 4, 5}) {
+		t.Errorf("FromInterface[int32] = %v, %v", ints, err)
+	}
+	strs, err := FromInterface[string]([]interface{}{"a", []byte("b"), 3})
+	if err != nil || !reflect.DeepEqual(strs, []string{"a", "b", "3"}) {
+		t.Errorf("FromInterface[string] = %v, %v", strs, err)
+	}
+
+	invalid := []struct {
+		name string
+		fn   func() error
+	}{
+		{"int from text", func() error { _, err := FromInterface[int]([]interface{}{1, "abc"}); return err }},
+		{"int8 overflow", func() error { _, err := FromInterface[int8]([]interface{}{300}); return err }},
+		{"negative uint", func() error { _, err := FromInterface[uint]([]interface{}{-1}); return err }},
+		{"float from text", func() error { _, err := FromInterface[float64]([]interface{}{"1.5x"}); return err }},
+		{"bool from text", func() error { _, err := FromInterface[bool]([]interface{}{"maybe"}); return err }},
+	}
+	for _, tt := range invalid {
+		if err := tt.fn(); err == nil {
+			t.Errorf("%s succeeded, want an error", tt.name)
+		}
+	}
+}
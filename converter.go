@@ -0,0 +1,50 @@
+package db
+
+import (
+	"reflect"
+	"sync"
+)
+
+var (
+	converterMu sync.RWMutex
+	converters  = map[reflect.Type]func(src interface{}) (interface{}, error){}
+)
+
+// Registers the function converting scanned values into fields of targetType, for types typeConvertor
+// does not know such as `type Email string` or big.Int wrappers. fn receives the value returned by the
+// driver, often []byte, and its result must be assignable or convertible to targetType.
+// The converter replaces the built-in conversions for targetType, including registered enums.
+func RegisterTypeConverter(targetType reflect.Type, fn func(src interface{}) (interface{}, error)) {
+	converterMu.Lock()
+	defer converterMu.Unlock()
+
+	converters[targetType] = fn
+}
+
+// Removes the converter registered for targetType.
+func UnregisterTypeConverter(targetType reflect.Type) {
+	converterMu.Lock()
+	defer converterMu.Unlock()
+
+	delete(converters, targetType)
+}
+
+func converterFor(t reflect.Type) (func(src interface{}) (interface{}, error), bool) {
+	converterMu.RLock()
+	defer converterMu.RUnlock()
+
+	fn, ok := converters[t]
+	return fn, ok
+}
+
+// Reports whether t, or the type t points to, has a registered converter.
+func hasConverter(t reflect.Type) bool {
+	if _, ok := converterFor(t); ok {
+		return true
+	}
+	if t.Kind() == reflect.Ptr {
+		_, ok := converterFor(t.Elem())
+		return ok
+	}
+	return false
+}
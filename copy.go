@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const defaultCopyBatchSize = 1000
+
+// Copies every row of srcTable into dstTable and returns the number of rows inserted. Rows are streamed
+// from the read pool and inserted on the write pool in multi-row INSERTs of batchSize rows (1000 by default),
+// so the table is never held in memory. The copy is not transactional: rows inserted before an error stay.
+//
+// transform may change, add or remove columns of each row, keyed by column name with nil for NULL.
+// Returning nil skips the row. Rows are copied as is when transform is nil.
+func CopyTable(ctx context.Context, srcTable, dstTable string, transform func(map[string]interface{}) map[string]interface{}, batchSize ...int) (int64, error) {
+	for _, table := range []string{srcTable, dstTable} {
+		if !identRegex.MatchString(table) {
+			return 0, fmt.Errorf("db: invalid identifier %q", table)
+		}
+	}
+
+	size := defaultCopyBatchSize
+	if len(batchSize) > 0 && batchSize[0] > 0 {
+		size = batchSize[0]
+	}
+
+	query := "SELECT * FROM " + quoteIdent(srcTable)
+	defer timer(query, nil)()
+
+	db, err := getPool(true)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := queryRows(ctx, db, query, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	srcCols, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	values := make([]interface{}, len(srcCols))
+	scans := make([]interface{}, len(srcCols))
+	for i := range scans {
+		scans[i] = &values[i]
+	}
+
+	var (
+		total     int64
+		batchCols []string
+		batch     [][]interface{}
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := insertValues(ctx, dstTable, batchCols, batch)
+		total += n
+		batch = batch[:0]
+		return err
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(scans...); err != nil {
+			return total, ClassifyError(err)
+		}
+
+		row := make(map[string]interface{}, len(srcCols))
+		for i, col := range srcCols {
+			row[col] = values[i]
+		}
+		if transform != nil {
+			if row = transform(row); row == nil {
+				continue
+			}
+		}
+
+		cols := rowColumns(row, srcCols)
+		if len(batch) >= size || !sameColumns(cols, batchCols) {
+			if err := flush(); err != nil {
+				return total, err
+			}
+			batchCols = cols
+		}
+
+		args := make([]interface{}, len(cols))
+		for i, col := range cols {
+			args[i] = row[col]
+		}
+		batch = append(batch, args)
+	}
+	if err := rows.Err(); err != nil {
+		return total, ClassifyError(err)
+	}
+
+	return total, flush()
+}
+
+// Returns the keys of row, in the order of order first and the others sorted.
+func rowColumns(row map[string]interface{}, order []string) []string {
+	cols := make([]string, 0, len(row))
+	for _, col := range order {
+		if _, ok := row[col]; ok {
+			cols = append(cols, col)
+		}
+	}
+
+	extra := len(cols)
+	for col := range row {
+		if IndexOf(col, order) < 0 {
+			cols = append(cols, col)
+		}
+	}
+	sort.Strings(cols[extra:])
+	return cols
+}
+
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Inserts rows of values for cols into table with one multi-row INSERT on the write pool.
+func insertValues(ctx context.Context, table string, cols []string, rows [][]interface{}) (int64, error) {
+	quoted := make([]string, len(cols))
+	for i, col := range cols {
+		quoted[i] = quoteIdent(col)
+	}
+
+	var (
+		groups []string
+		args   []interface{}
+	)
+	for _, row := range rows {
+		groups = append(groups, "("+placeholders(len(row))+")")
+		args = append(args, row...)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", quoteIdent(table), strings.Join(quoted, ", "), strings.Join(groups, ", "))
+	db, err := getPool(false)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := execFrom(ctx, db, query, args)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -1,126 +1,425 @@
+// Package db runs MySQL queries and scans the rows into structs.
+//
+// The package-level functions share one read and one write connection pool, configured through the
+// environment and opened on first use. The pools live until CloseDB or DrainAndClose is called, after
+// which the next query opens them again: callers no longer open or close a *sql.DB per query.
+// GetDB and New open pools of their own, which the caller must close.
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"os"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/go-sql-driver/mysql"
 	"github.com/spf13/cast"
 )
 
 var (
-	logging bool
+	logging atomic.Bool
 )
 
+// Executes the query and returns the first row, or nil without an error when no row is found.
 // Pls enhance the query by incorporating the 'limit 1' parameter to optimize speed.
-func One[T any](query string, args []interface{}) *T {
-	defer timer(queryToString(query, args))()
+func One[T any](query string, args []interface{}) (*T, error) {
+	return OneCtx[T](context.Background(), query, args)
+}
+
+// Same as One but honours the deadline and cancellation of ctx.
+func OneCtx[T any](ctx context.Context, query string, args []interface{}) (*T, error) {
+	return OneOnCtx[T](ctx, defaultDB, query, args)
+}
+
+// Same as OneCtx but runs on q, e.g. a transaction.
+func OneIn[T any](ctx context.Context, q Queryer, query string, args []interface{}) (*T, error) {
+	res, found, err := oneFrom[T](ctx, q, query, args)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Same as One, kept for compatibility.
+func OneE[T any](query string, args []interface{}) (*T, error) {
+	return OneCtx[T](context.Background(), query, args)
+}
 
-	db := GetDB()
-	defer db.Close()
+// Executes the query and returns the first row as a value.
+// When no row is found the zero value of T is returned without an error.
+func FirstOrDefault[T any](query string, args []interface{}) (T, error) {
+	defer timer(query, args)()
+
+	var res T
+	db, err := getPool()
+	if err != nil {
+		return res, err
+	}
 
-	rows, err := db.Query(query, args...)
-	handleError("Error On Get Rows", err)
+	rows, err := queryRows(context.Background(), db, query, args)
+	if err != nil {
+		return res, err
+	}
 	defer rows.Close()
 
-	if rows.Next() {
-		// var structData T
-		// mapToStruct(resultToMap(rows), &structData)
-		structData := ScanStruct[T](rows)
-		return &structData
-	} else {
-		return nil
+	if !rows.Next() {
+		return res, ClassifyError(rows.Err())
+	}
+
+	return scanStruct[T](rows)
+}
+
+// Executes the query and returns the first row by value, avoiding the heap allocation of One.
+// found is false when no row matches.
+func QueryOne[T any](ctx context.Context, query string, args []interface{}) (result T, found bool, err error) {
+	db, err := dbFromContext(ctx)
+	if err != nil {
+		return result, false, err
 	}
+	return oneFrom[T](ctx, db, query, args)
 }
 
-func All[T any](query string, args []interface{}) []T {
-	defer timer(queryToString(query, args))()
+// Executes the query and returns the first row, or ErrNoRows when no row matches.
+func OneOrError[T any](ctx context.Context, query string, args []interface{}) (T, error) {
+	db, err := dbFromContext(ctx)
+	if err != nil {
+		var res T
+		return res, err
+	}
+	res, found, err := oneFrom[T](ctx, db, query, args)
+	if err == nil && !found {
+		err = ErrNoRows
+	}
+	return res, err
+}
 
-	db := GetDB()
-	defer db.Close()
+func oneFrom[T any](ctx context.Context, db Queryer, query string, args []interface{}) (result T, found bool, err error) {
+	defer timer(query, args)()
 
-	rows, err := db.Query(query, args...)
-	handleError("Error On Get Rows", err)
+	rows, err := queryRows(ctx, db, query, args)
+	if err != nil {
+		return result, false, err
+	}
 	defer rows.Close()
 
-	var res []T
+	if !rows.Next() {
+		return result, false, ClassifyError(rows.Err())
+	}
+
+	result, err = scanStruct[T](rows)
+	return result, err == nil, err
+}
+
+// Executes the query and returns every row.
+func All[T any](query string, args []interface{}) ([]T, error) {
+	return allContext[T](context.Background(), query, args)
+}
+
+// Same as All but honours the deadline and cancellation of ctx.
+func AllCtx[T any](ctx context.Context, query string, args []interface{}) ([]T, error) {
+	return allContext[T](ctx, query, args)
+}
+
+// Same as AllCtx but runs on q, e.g. a transaction.
+func AllIn[T any](ctx context.Context, q Queryer, query string, args []interface{}) ([]T, error) {
+	return allFrom[T](ctx, q, query, args)
+}
+
+// Same as All, kept for compatibility.
+func AllE[T any](query string, args []interface{}) ([]T, error) {
+	return allContext[T](context.Background(), query, args)
+}
+
+// Same as All. Named for users coming from other SQL libraries.
+func Query[T any](query string, args []interface{}) ([]T, error) {
+	return allContext[T](context.Background(), query, args)
+}
+
+// Executes the query and passes each row to fn as soon as it is scanned, without building a slice.
+// Iteration stops at the first error returned by fn, which is then returned as is.
+func ForEach[T any](ctx context.Context, query string, args []interface{}, fn func(T) error) error {
+	defer timer(query, args)()
+
+	db, err := dbFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows, err := queryRows(ctx, db, query, args)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	scanner := newRowScanner[T]()
 	for rows.Next() {
-		// var structData T
-		// mapToStruct(resultToMap(rows), &structData)
-		res = append(res, ScanStruct[T](rows))
+		structData, err := scanner.Scan(rows)
+		if err != nil {
+			return ClassifyError(err)
+		}
+
+		if err := fn(structData); err != nil {
+			return err
+		}
+	}
+
+	return ClassifyError(rows.Err())
+}
+
+// Executes the query and a COUNT(*) over it concurrently, each on its own read connection.
+// The total is the number of rows the query matches, which is useful when the query itself is paged with LIMIT.
+func AllWithTotal[T any](ctx context.Context, query string, args []interface{}) (items []T, total int64, err error) {
+	var (
+		wg       sync.WaitGroup
+		countErr error
+	)
+
+	countQuery := "SELECT COUNT(*) FROM (" + strings.TrimRight(query, "; \t\r\n") + ") AS t"
+
+	// A pinned connection cannot run two queries at once.
+	if _, ok := pinnedConn(ctx); ok {
+		countErr = ColumnCtx(ctx, countQuery, args, &total)
+	} else {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			countErr = ColumnCtx(ctx, countQuery, args, &total)
+		}()
+	}
+
+	items, err = allContext[T](ctx, query, args)
+	wg.Wait()
+
+	if err == nil {
+		err = countErr
+	}
+	return items, total, err
+}
+
+func allContext[T any](ctx context.Context, query string, args []interface{}) ([]T, error) {
+	return AllOnCtx[T](ctx, defaultDB, query, args)
+}
+
+func allFrom[T any](ctx context.Context, db Queryer, query string, args []interface{}) ([]T, error) {
+	defer timer(query, args)()
+
+	rows, err := queryRows(ctx, db, query, args)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return res
+	return scanAll[T](rows)
 }
 
 // Executes the query and returns the first column of the result
 func Column(query string, args []interface{}, dest ...any) error {
-	defer timer(queryToString(query, args))()
+	return ColumnCtx(context.Background(), query, args, dest...)
+}
+
+// Same as Column but honours the deadline and cancellation of ctx.
+func ColumnCtx(ctx context.Context, query string, args []interface{}, dest ...any) error {
+	db, err := dbFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	return columnFrom(ctx, db, query, args, dest...)
+}
+
+// Same as ColumnCtx but runs on q, e.g. a transaction.
+func ColumnIn(ctx context.Context, q Queryer, query string, args []interface{}, dest ...any) error {
+	return columnFrom(ctx, q, query, args, dest...)
+}
+
+func columnFrom(ctx context.Context, db Queryer, query string, args []interface{}, dest ...any) error {
+	defer timer(query, args)()
+
+	rows, err := queryRows(ctx, db, query, args)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return ClassifyError(err)
+		}
+		return ErrNoRows
+	}
+
+	if err := rows.Scan(dest...); err != nil {
+		return ClassifyError(err)
+	}
+	return ClassifyError(rows.Close())
+}
+
+// Executes the query and returns the first column of every row
+func ColumnSlice[T any](query string, args []interface{}) ([]T, error) {
+	return ColumnSliceCtx[T](context.Background(), query, args)
+}
+
+// Same as ColumnSlice but honours the deadline and cancellation of ctx.
+func ColumnSliceCtx[T any](ctx context.Context, query string, args []interface{}) ([]T, error) {
+	defer timer(query, args)()
+
+	db, err := dbFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := queryRows(ctx, db, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
 
-	db := GetDB()
-	defer db.Close()
+	// Only the first column is kept
+	scans := make([]interface{}, len(cols))
+	for i := range scans {
+		scans[i] = &scans[i]
+	}
+
+	var res []T
+	for rows.Next() {
+		var value T
+		scans[0] = &value
+		if err := rows.Scan(scans...); err != nil {
+			return res, ClassifyError(err)
+		}
+		res = append(res, value)
+	}
 
-	row := db.QueryRow(query, args...)
-	err := row.Scan(dest...)
-	return err
+	return res, ClassifyError(rows.Err())
 }
 
 // Executes the SQL statement and returns ALL rows at once
-func QueryAll(query string, args []interface{}) []map[string]interface{} {
-	defer timer(queryToString(query, args))()
+func QueryAll(query string, args []interface{}) ([]Row, error) {
+	defer timer(query, args)()
 
-	db := GetDB()
-	defer db.Close()
+	db, err := getPool()
+	if err != nil {
+		return nil, err
+	}
 
-	rows, err := db.Query(query, args...)
-	handleError("Error On Get Rows", err)
+	rows, err := queryRows(context.Background(), db, query, args)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
-	var res []map[string]interface{}
+	var res []Row
 	for rows.Next() {
-		res = append(res, resultToMap(rows))
+		row, err := scanRowMap(rows)
+		if err != nil {
+			return nil, ClassifyError(err)
+		}
+		res = append(res, row)
 	}
+	return res, ClassifyError(rows.Err())
+}
 
-	return res
+// Same as QueryAll, kept for compatibility.
+func QueryAllE(query string, args []interface{}) ([]Row, error) {
+	return QueryAll(query, args)
 }
 
 // Deprecated: Unable to close the rows and database connection after the query is completed.
 // This function will retain the database connection in the pool.
-func GetRows(query string, args []interface{}) *sql.Rows {
-	defer timer(queryToString(query, args))()
+func GetRows(query string, args []interface{}) (*sql.Rows, error) {
+	defer timer(query, args)()
+
+	db, err := getPool()
+	if err != nil {
+		return nil, err
+	}
+	return queryRows(context.Background(), db, query, args)
+}
 
-	db := GetDB()
-	defer db.Close()
+func Exec(query string, args []interface{}) (sql.Result, error) {
+	return ExecCtx(context.Background(), query, args)
+}
 
-	rows, err := db.Query(query, args...)
-	handleError("Error On Get Rows", err)
+// Same as Exec but honours the deadline and cancellation of ctx.
+func ExecCtx(ctx context.Context, query string, args []interface{}) (sql.Result, error) {
+	res, err := defaultDB.ExecCtx(ctx, query, args)
+	if err == nil {
+		publishQueryChange(query, args)
+	}
+	return res, err
+}
 
-	return rows
+// Same as ExecCtx but runs on q, e.g. a transaction. No change event is published,
+// as the statement may still be rolled back.
+func ExecIn(ctx context.Context, q Queryer, query string, args []interface{}) (sql.Result, error) {
+	return execFrom(ctx, q, query, args)
 }
 
-func Exec(query string, args []interface{}) (sql.Result, error) {
-	defer timer(queryToString(query, args))()
+// Executes the query like All and also returns how long the query and scan took.
+func QueryWithDuration[T any](ctx context.Context, query string, args []interface{}) ([]T, time.Duration, error) {
+	start := time.Now()
+	res, err := allContext[T](ctx, query, args)
+	return res, time.Since(start), err
+}
+
+// Same as Exec but honours ctx and also returns how long the statement took.
+func ExecWithDuration(ctx context.Context, query string, args []interface{}) (sql.Result, time.Duration, error) {
+	db, err := dbFromContext(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	start := time.Now()
+	res, err := execFrom(ctx, db, query, args)
+	elapsed := time.Since(start)
+	if err == nil {
+		publishQueryChange(query, args)
+	}
+	return res, elapsed, err
+}
 
-	db := GetDB(false)
-	defer db.Close()
+func execFrom(ctx context.Context, db Queryer, query string, args []interface{}) (sql.Result, error) {
+	query, args, err := expandSliceArgs(query, args)
+	if err != nil {
+		return nil, err
+	}
+	query = applyQueryOptions(ctx, applyQueryFilter(ctx, query))
+	if res, skipped := skipDryRun(query, args); skipped {
+		return res, nil
+	}
+	logMatchingPlan(ctx, db, query, args)
 
-	return db.Exec(query, args...)
+	defer timer(query, args)()
+
+	var res sql.Result
+	if pool, ok := db.(*sql.DB); ok {
+		res, err = cachedExec(ctx, pool, query, args)
+	} else {
+		res, err = db.ExecContext(ctx, query, args...)
+	}
+	return res, ClassifyError(err)
 }
 
 func SetLogging(isLogging bool) {
-	logging = isLogging
+	logging.Store(isLogging)
 }
 
 func GetIsLogging() bool {
-	return logging
+	return logging.Load()
 }
 
-// The responsibility to close the database connection must be handled externally when calling this method.
+// Opens a new pool on the database configured through the environment, separate from the shared pools
+// the package-level functions use. The responsibility to close the database connection must be handled
+// externally when calling this method.
 //
 // This function WILL NOT automatically close the rows and database connection after the query is executed.
 func GetDB(readOnly ...bool) *sql.DB {
@@ -128,69 +427,56 @@ func GetDB(readOnly ...bool) *sql.DB {
 		readOnly = append(readOnly, true)
 	}
 
-	dbConfig := &mysql.Config{
-		DBName:               getEnv("DATABASE_NAME"),
-		Net:                  getEnv("DATABASE_MODE"),
-		ParseTime:            true,
-		AllowNativePasswords: true,
-	}
-
-	if readOnly[0] {
-		dbConfig.User = getEnv("DATABASE_READ_USERNAME")
-		dbConfig.Passwd = getEnv("DATABASE_READ_PASSWORD")
-		dbConfig.Addr = getEnv("DATABASE_READ_HOST")
-	}
-
-	if dbConfig.User == "" || dbConfig.Passwd == "" || dbConfig.Addr == "" {
-		dbConfig.User = getEnv("DATABASE_USERNAME")
-		dbConfig.Passwd = getEnv("DATABASE_PASSWORD")
-		dbConfig.Addr = getEnv("DATABASE_HOST")
-	}
-
-	db, err := sql.Open("mysql", dbConfig.FormatDSN())
-	if err != nil {
-		handleError("Error Open Connection DB", err)
-	}
-
-	// Check the connectivity by pinging the database
-	if err := db.Ping(); err != nil {
-		handleError("Error connecting to the database", err)
-	}
+	db, err := openEnvPool(readOnly[0])
+	handleError("Error connecting to the database", err)
 
 	return db
 }
 
+// Renders the query with its arguments for logging, hiding any sensitive values.
 func queryToString(query string, args []interface{}) string {
+	// Comments are dropped so they cannot forge log lines
+	if sanitized, err := SanitizeSQL(query); err == nil {
+		query = sanitized
+	}
+	return truncateForLog(interpolateQuery(query, redactArgs(query, args)))
+}
+
+func interpolateQuery(query string, args []interface{}) string {
 	if len(args) == 0 {
 		return query
 	}
 
 	new := fmt.Sprintf("'%v'", args[0])
 	switch value := args[0].(type) {
-	case bool, int, float64:
+	case bool, int, float64, redacted:
 		new = fmt.Sprintf("%v", value)
 	}
 
 	query = strings.Replace(query, "?", new, 1)
-	return queryToString(query, args[1:])
+	return interpolateQuery(query, args[1:])
 }
 
-func resultToMap(list *sql.Rows) map[string]interface{} {
-	fields, _ := list.Columns()               // fieldName
+// Scans the current row into a map keyed by column name, leaving NULL columns out.
+func scanRowMap(list *sql.Rows) (map[string]interface{}, error) {
+	fields, err := list.Columns()             // fieldName
 	scans := make([]interface{}, len(fields)) // value
 	row := make(map[string]interface{})       // result
+	if err != nil {
+		return row, err
+	}
 
 	for i := range scans {
 		scans[i] = &scans[i]
 	}
-	list.Scan(scans...)
+	err = list.Scan(scans...)
 	for i, v := range scans {
 		if v != nil {
 			row[fields[i]] = v
 		}
 	}
 
-	return row
+	return row, err
 }
 
 func mapToStruct(data map[string]interface{}, target interface{}) {
@@ -198,18 +484,24 @@ func mapToStruct(data map[string]interface{}, target interface{}) {
 	rv := reflect.ValueOf(target).Elem()
 
 	for i := 0; i < rt.NumField(); i++ {
-		fieldName := rt.Field(i).Name
+		fieldName := columnName(rt.Field(i))
 		fieldType := rt.Field(i).Type
-		createdAtField, _ := rt.FieldByName(fieldName)
-		jsonTag := createdAtField.Tag.Get("json")
 
-		if jsonTag != "" {
-			fieldName = jsonTag
-		} else {
-			fieldName = strings.ToLower(fieldName)
+		if fieldName == "" {
+			continue
+		}
+
+		value, ok := data[fieldName]
+		if !ok {
+			// user_id column for a UserID field
+			value, ok = data[toSnake(rt.Field(i).Name)]
+		}
+		if !ok {
+			// userid column, the mapping of earlier versions
+			value, ok = data[strings.ToLower(rt.Field(i).Name)]
 		}
 
-		if value, ok := data[fieldName]; ok {
+		if ok {
 			value = typeConvertor(value, fieldType)
 
 			if fieldType.Kind() == reflect.Ptr && value != nil {
@@ -247,12 +539,37 @@ func typeConvertor(value interface{}, targetType reflect.Type) interface{} {
 		return value
 	}
 
+	// Conversion errors cannot be reported here, the value is dropped. setFieldFromInterface reports them.
+	if fn, ok := converterFor(targetType); ok {
+		converted, err := fn(value)
+		if err != nil {
+			return nil
+		}
+		return converted
+	}
+
 	if targetType.Kind() == reflect.Ptr {
 		if value == "" {
 			return nil
 		}
 
 		targetType = targetType.Elem()
+		if fn, ok := converterFor(targetType); ok {
+			converted, err := fn(value)
+			if err != nil {
+				return nil
+			}
+			return converted
+		}
+	}
+
+	// Unknown enum values cannot be reported here, they are dropped. setFieldFromInterface reports them.
+	if isEnumType(targetType) {
+		s, err := enumValue(targetType, value)
+		if err != nil {
+			return reflect.Zero(targetType).Interface()
+		}
+		return reflect.ValueOf(s).Convert(targetType).Interface()
 	}
 
 	switch targetType.Kind() {
@@ -279,37 +596,66 @@ func typeConvertor(value interface{}, targetType reflect.Type) interface{} {
 }
 
 func ScanStruct[T any](row *sql.Rows) (structData T) {
-	fields, _ := row.Columns()                // fieldName
-	scans := make([]interface{}, len(fields)) // value
+	structData, _ = scanStruct[T](row)
+	return structData
+}
 
-	for i := range scans {
-		scans[i] = &scans[i]
-	}
+// Same as ScanStruct but returns the scan error instead of discarding it.
+func ScanRowColumns[T any](rows *sql.Rows) (T, error) {
+	return scanStruct[T](rows)
+}
 
-	rt := reflect.TypeOf(structData)
-	rv := reflect.ValueOf(&structData).Elem()
-	for i := 0; i < rt.NumField(); i++ {
-		fieldName := rt.Field(i).Name
-		createdAtField, _ := rt.FieldByName(fieldName)
-		jsonTag := createdAtField.Tag.Get("json")
-
-		if jsonTag != "" {
-			fieldName = jsonTag
-		} else {
-			fieldName = strings.ToLower(fieldName)
-		}
+// Scans every remaining row of rows, e.g. from GetRows, stopping at the first scan error.
+// The caller still owns rows and must close it.
+func ScanStructs[T any](rows *sql.Rows) ([]T, error) {
+	return scanAll[T](rows)
+}
 
-		idx := IndexOf(fieldName, fields)
+// Scans a single *sql.Row (e.g. from db.QueryRow) into a struct.
+//
+// *sql.Row does not expose its column names, so the query MUST select the columns
+// in the same order as the struct fields are declared.
+func ScanRow[T any](row *sql.Row) (structData T, err error) {
+	rv := reflect.ValueOf(&structData).Elem()
+	if rv.Kind() != reflect.Struct {
+		return structData, fmt.Errorf("db: expected a struct, got %T", structData)
+	}
 
-		if idx < 0 {
+	var scans []interface{}
+	for i := 0; i < rv.NumField(); i++ {
+		if columnName(rv.Type().Field(i)) == "" {
 			continue
 		}
+		scans = append(scans, rv.Field(i).Addr().Interface())
+	}
+
+	err = row.Scan(scans...)
+	return structData, err
+}
 
-		scans[idx] = rv.Field(i).Addr().Interface()
+func scanAll[T any](rows *sql.Rows) ([]T, error) {
+	var res []T
+	scanner := newRowScanner[T]()
+	for rows.Next() {
+		structData, err := scanner.Scan(rows)
+		if err != nil {
+			return res, ClassifyError(err)
+		}
+		res = append(res, structData)
 	}
 
-	row.Scan(scans...)
-	return structData
+	return res, ClassifyError(rows.Err())
+}
+
+func scanStruct[T any](row *sql.Rows) (structData T, err error) {
+	scanner, pool := getScanner[T]()
+	defer func() {
+		scanner.reset()
+		pool.Put(scanner)
+	}()
+
+	structData, err = scanner.Scan(row)
+	return structData, ClassifyError(err)
 }
 
 func getEnv(k string) string {
@@ -324,10 +670,14 @@ func handleError(info string, err error) {
 	}
 }
 
-func timer(query string) func() {
-	if logging {
+// Returns a function logging how long the query took when logging is enabled.
+// The query is only rendered for the log when logging is enabled.
+func timer(query string, args []interface{}) func() {
+	if logging.Load() {
 		st := time.Now()
-		return func() { log.Printf("[%.2fms] %s \n", float64(time.Since(st).Milliseconds()), query) }
+		return func() {
+			log.Printf("[%.2fms] %s \n", float64(time.Since(st).Milliseconds()), queryToString(query, args))
+		}
 	}
 	return func() {}
 }
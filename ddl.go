@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	nullSQLTypes = map[reflect.Type]string{
+		reflect.TypeOf(sql.NullString{}):  "VARCHAR(255)",
+		reflect.TypeOf(sql.NullInt64{}):   "BIGINT",
+		reflect.TypeOf(sql.NullInt32{}):   "INT",
+		reflect.TypeOf(sql.NullInt16{}):   "SMALLINT",
+		reflect.TypeOf(sql.NullByte{}):    "TINYINT UNSIGNED",
+		reflect.TypeOf(sql.NullFloat64{}): "DOUBLE",
+		reflect.TypeOf(sql.NullBool{}):    "TINYINT(1)",
+		reflect.TypeOf(sql.NullTime{}):    "DATETIME",
+	}
+)
+
+// Returns the CREATE TABLE IF NOT EXISTS statement of T. The table is named as in Find and
+// the primary key is the field tagged `db:"name,pk"` or named ID.
+//
+// Pointer and sql.Null* fields are nullable, other fields are NOT NULL. The MySQL type is inferred
+// from the Go type unless overridden with a type option, which must come last in the tag, e.g.
+// `db:"amount,type:DECIMAL(10,2) NOT NULL DEFAULT 0"`. The override is used verbatim. Without a column
+// name, as in `db:"type:BIGINT UNSIGNED"`, the column is named as if the field had no `db` tag.
+func CreateTableSQL[T any]() (string, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return "", fmt.Errorf("db: expected a struct, got %s", t)
+	}
+
+	var (
+		defs []string
+		err  error
+	)
+	eachColumn(t, nil, func(i int, field reflect.StructField, col string) {
+		if err != nil {
+			return
+		}
+
+		if override, ok := typeOption(field); ok {
+			defs = append(defs, quoteIdent(col)+" "+override)
+			return
+		}
+
+		sqlType, nullable, ok := sqlTypeOf(field.Type)
+		if !ok {
+			err = fmt.Errorf("db: no MySQL type for field %s of type %s, add a type option to its tag", field.Name, field.Type)
+			return
+		}
+
+		def := quoteIdent(col) + " " + sqlType
+		if !nullable {
+			def += " NOT NULL"
+		}
+		defs = append(defs, def)
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(defs) == 0 {
+		return "", fmt.Errorf("db: %s has no columns", t)
+	}
+
+	if pk, ok := primaryKey(t); ok {
+		defs = append(defs, "PRIMARY KEY ("+quoteIdent(pk)+")")
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n\t%s\n)", quoteIdent(tableName(t)), strings.Join(defs, ",\n\t")), nil
+}
+
+// Returns the DROP TABLE IF EXISTS statement of T, the table being named as in Find.
+func DropTableSQL[T any]() string {
+	return "DROP TABLE IF EXISTS " + quoteIdent(tableName(reflect.TypeOf((*T)(nil)).Elem()))
+}
+
+// Executes a DDL statement on the write pool. DDL is never run as a prepared statement
+// and is logged with a DDL label instead of the interpolated query.
+func ExecDDL(ctx context.Context, ddl string) error {
+	if _, skipped := skipDryRun("DDL "+ddl, nil); skipped {
+		return nil
+	}
+
+	defer timer("DDL "+ddl, nil)()
+
+	db, err := dbFromContext(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, ddl)
+	return ClassifyError(err)
+}
+
+// Returns the value of the type option of the `db` tag, which spans the rest of the tag.
+// The option may also take the place of the column name.
+func typeOption(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("db")
+	if value, ok := strings.CutPrefix(strings.TrimSpace(tag), "type:"); ok {
+		return strings.TrimSpace(value), true
+	}
+
+	_, opts, _ := strings.Cut(tag, ",")
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if value, ok := strings.CutPrefix(strings.TrimSpace(opt), "type:"); ok {
+			if opts != "" {
+				value += "," + opts
+			}
+			return strings.TrimSpace(value), true
+		}
+	}
+	return "", false
+}
+
+// Maps a Go type to its MySQL column type and whether the column accepts NULL.
+func sqlTypeOf(t reflect.Type) (sqlType string, nullable bool, ok bool) {
+	if t.Kind() == reflect.Ptr {
+		sqlType, _, ok = sqlTypeOf(t.Elem())
+		return sqlType, true, ok
+	}
+
+	if sqlType, ok := nullSQLTypes[t]; ok {
+		return sqlType, true, true
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "TINYINT(1)", false, true
+	case reflect.Int8:
+		return "TINYINT", false, true
+	case reflect.Int16:
+		return "SMALLINT", false, true
+	case reflect.Int32:
+		return "INT", false, true
+	case reflect.Int, reflect.Int64:
+		return "BIGINT", false, true
+	case reflect.Uint8:
+		return "TINYINT UNSIGNED", false, true
+	case reflect.Uint16:
+		return "SMALLINT UNSIGNED", false, true
+	case reflect.Uint32:
+		return "INT UNSIGNED", false, true
+	case reflect.Uint, reflect.Uint64:
+		return "BIGINT UNSIGNED", false, true
+	case reflect.Float32:
+		return "FLOAT", false, true
+	case reflect.Float64:
+		return "DOUBLE", false, true
+	case reflect.String:
+		return "VARCHAR(255)", false, true
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "BLOB", true, true
+		}
+	case reflect.Map:
+		return "JSON", true, true
+	case reflect.Struct:
+		if t == timeType {
+			return "DATETIME", false, true
+		}
+	}
+	return "", false, false
+}
+
+// Reports whether tableName exists in the DATABASE_NAME schema, or the connection's current schema when it is unset.
+func TableExists(ctx context.Context, tableName string) (bool, error) {
+	var n int
+	err := ColumnCtx(internalQuery(ctx), "SELECT COUNT(*) FROM information_schema.TABLES WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ?",
+		[]interface{}{getEnv("DATABASE_NAME"), tableName}, &n)
+	return n > 0, err
+}
+
+// Reports whether tableName has a column named columnName, looked up in the same schema as TableExists.
+func ColumnExists(ctx context.Context, tableName, columnName string) (bool, error) {
+	var n int
+	err := ColumnCtx(internalQuery(ctx), "SELECT COUNT(*) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ? AND COLUMN_NAME = ?",
+		[]interface{}{getEnv("DATABASE_NAME"), tableName, columnName}, &n)
+	return n > 0, err
+}
+
+// Maps each column of T to the MySQL type of that column in tableName, as reported by information_schema,
+// e.g. "varchar(255)" or "bigint unsigned". Columns of T missing from the table are left out.
+func ColumnTypes[T any](tableName string) (map[string]string, error) {
+	type columnType struct {
+		Name string `db:"column_name"`
+		Type string `db:"column_type"`
+	}
+	tableCols, err := allContext[columnType](internalQuery(context.Background()),
+		"SELECT COLUMN_NAME AS column_name, COLUMN_TYPE AS column_type FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = COALESCE(NULLIF(?, ''), DATABASE()) AND TABLE_NAME = ?",
+		[]interface{}{getEnv("DATABASE_NAME"), tableName})
+	if err != nil {
+		return nil, err
+	}
+	if len(tableCols) == 0 {
+		return nil, fmt.Errorf("db: table %q not found", tableName)
+	}
+
+	byName := make(map[string]string, len(tableCols))
+	for _, col := range tableCols {
+		byName[strings.ToLower(col.Name)] = col.Type
+	}
+
+	types := map[string]string{}
+	for _, col := range ColumnNames[T]() {
+		if sqlType, ok := byName[strings.ToLower(col)]; ok {
+			types[col] = sqlType
+		}
+	}
+	return types, nil
+}
@@ -0,0 +1,28 @@
+package db
+
+import "testing"
+
+type ddlOrder struct {
+	ID       uint64  `db:"type:BIGINT UNSIGNED AUTO_INCREMENT"`
+	Amount   float64 `db:"amount,type:DECIMAL(10,2) NOT NULL DEFAULT 0"`
+	Note     *string `json:"note"`
+	Customer string  `db:"type:VARCHAR(64) NOT NULL" json:"customer_name"`
+}
+
+func TestCreateTableSQL(t *testing.T) {
+	got, err := CreateTableSQL[ddlOrder]()
+	if err != nil {
+		t.Fatalf("CreateTableSQL error = %v", err)
+	}
+
+	want := "CREATE TABLE IF NOT EXISTS `ddl_orders` (\n" +
+		"\t`id` BIGINT UNSIGNED AUTO_INCREMENT,\n" +
+		"\t`amount` DECIMAL(10,2) NOT NULL DEFAULT 0,\n" +
+		"\t`note` VARCHAR(255),\n" +
+		"\t`customer_name` VARCHAR(64) NOT NULL,\n" +
+		"\tPRIMARY KEY (`id`)\n" +
+		")"
+	if got != want {
+		t.Errorf("CreateTableSQL =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Builds `UPDATE table SET col = ?, ... WHERE pk = ?` for the fields that differ between old and new,
+// compared with reflect.DeepEqual. changed is false, and no statement is built, when they are identical.
+//
+// The primary key is found as in Find and its value is taken from old. T may be a pointer to a struct;
+// nil pointers, non-structs and a T without primary key are errors.
+func DiffUpdate[T any](table string, old T, new T) (query string, args []interface{}, changed bool, err error) {
+	ov, nv := reflect.ValueOf(old), reflect.ValueOf(new)
+	if ov.Kind() == reflect.Ptr {
+		if ov.IsNil() || nv.IsNil() {
+			return "", nil, false, fmt.Errorf("db: nil %T", old)
+		}
+		ov, nv = ov.Elem(), nv.Elem()
+	}
+	if ov.Kind() != reflect.Struct {
+		return "", nil, false, fmt.Errorf("db: %T is not a struct", old)
+	}
+
+	pk, ok := primaryKey(ov.Type())
+	if !ok {
+		return "", nil, false, fmt.Errorf("db: %s has no primary key field, tag one with `db:\"name,pk\"`", ov.Type())
+	}
+
+	var (
+		sets    []string
+		pkValue interface{}
+	)
+	eachColumn(ov.Type(), nil, func(i int, field reflect.StructField, col string) {
+		if col == pk {
+			pkValue = ov.Field(i).Interface()
+		}
+		if reflect.DeepEqual(ov.Field(i).Interface(), nv.Field(i).Interface()) {
+			return
+		}
+		sets = append(sets, quoteIdent(col)+" = ?")
+		args = append(args, nv.Field(i).Interface())
+	})
+
+	if len(sets) == 0 {
+		return "", nil, false, nil
+	}
+
+	query = fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?", quoteIdent(table), strings.Join(sets, ", "), quoteIdent(pk))
+	return query, append(args, pkValue), true, nil
+}
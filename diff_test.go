@@ -0,0 +1,39 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffUpdate(t *testing.T) {
+	type user struct {
+		ID    int64
+		Name  string
+		Email string
+	}
+
+	old, new := &user{ID: 1, Name: "a", Email: "a@x"}, &user{ID: 1, Name: "b", Email: "a@x"}
+	query, args, changed, err := DiffUpdate("users", old, new)
+	if err != nil || !changed {
+		t.Fatalf("DiffUpdate = changed %t, error %v", changed, err)
+	}
+	if want := "UPDATE `users` SET `name` = ? WHERE `id` = ?"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if want := []interface{}{"b", int64(1)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+
+	if _, _, changed, err := DiffUpdate("users", *old, *old); err != nil || changed {
+		t.Errorf("identical rows = changed %t, error %v", changed, err)
+	}
+	if _, _, _, err := DiffUpdate("users", old, (*user)(nil)); err == nil {
+		t.Error("nil pointer succeeded, want an error")
+	}
+	if _, _, _, err := DiffUpdate("users", 1, 2); err == nil {
+		t.Error("int succeeded, want an error")
+	}
+	if _, _, _, err := DiffUpdate("users", struct{ Name string }{"a"}, struct{ Name string }{"b"}); err == nil {
+		t.Error("struct without primary key succeeded, want an error")
+	}
+}
@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+)
+
+// An in-memory driver answering every query with the same rows, for tests that need a pool
+// without a MySQL server.
+type fakeConnector struct {
+	columns []string
+	rows    [][]driver.Value
+	rowsErr error // returned by Next after the rows instead of io.EOF
+
+	queryErr func(query string) error // fails matching queries when set
+
+	// Answers queries instead of columns and rows when set, e.g. to emulate a table
+	respond func(query string, args []driver.Value) ([]string, [][]driver.Value)
+
+	mu    sync.Mutex
+	execs []string // statements run, in order
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{c}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver {
+	return fakeDriver{c}
+}
+
+// Opens a pool on a fake connector.
+func openFakeDB(columns []string, rows ...[]driver.Value) *sql.DB {
+	db, _ := openFakeConnector(columns, rows...)
+	return db
+}
+
+// Same as openFakeDB but also returns the connector, to inspect the statements run.
+func openFakeConnector(columns []string, rows ...[]driver.Value) (*sql.DB, *fakeConnector) {
+	c := &fakeConnector{columns: columns, rows: rows}
+	return sql.OpenDB(c), c
+}
+
+func (c *fakeConnector) exec(query string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.execs = append(c.execs, query)
+}
+
+// Returns the statements run so far.
+func (c *fakeConnector) statements() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string(nil), c.execs...)
+}
+
+// Replaces the shared pools with pool for the duration of the test.
+func useSharedPool(t testing.TB, pool *sql.DB) {
+	CloseDB()
+	open := openSharedPool
+	openSharedPool = func(bool) (*sql.DB, error) {
+		return pool, nil
+	}
+	t.Cleanup(func() {
+		CloseDB()
+		openSharedPool = open
+	})
+}
+
+func (c *fakeConnector) query(query string, args []driver.Value) (driver.Rows, error) {
+	if c.queryErr != nil {
+		if err := c.queryErr(query); err != nil {
+			return nil, err
+		}
+	}
+	if c.respond != nil {
+		columns, rows := c.respond(query, args)
+		return &fakeRows{c: c, columns: columns, rows: rows}, nil
+	}
+	return &fakeRows{c: c, columns: c.columns, rows: c.rows}, nil
+}
+
+type fakeDriver struct {
+	c *fakeConnector
+}
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{d.c}, nil
+}
+
+type fakeConn struct {
+	c *fakeConnector
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{c.c, query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, named []driver.NamedValue) (driver.Rows, error) {
+	args := make([]driver.Value, len(named))
+	for i, arg := range named {
+		args[i] = arg.Value
+	}
+	return c.c.query(query, args)
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.c.exec(query)
+	return driver.RowsAffected(0), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error {
+	return nil
+}
+
+func (fakeTx) Rollback() error {
+	return nil
+}
+
+type fakeStmt struct {
+	c     *fakeConnector
+	query string
+}
+
+func (s fakeStmt) Close() error {
+	return nil
+}
+
+func (s fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.c.exec(s.query)
+	return driver.RowsAffected(0), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.c.query(s.query, args)
+}
+
+type fakeRows struct {
+	c       *fakeConnector
+	columns []string
+	rows    [][]driver.Value
+	n       int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.n >= len(r.rows) {
+		if r.c.rowsErr != nil {
+			return r.c.rowsErr
+		}
+		return io.EOF
+	}
+	copy(dest, r.rows[r.n])
+	r.n++
+	return nil
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"database/sql"
+	"log"
+	"sync/atomic"
+)
+
+var dryRun atomic.Bool
+
+// Makes every write (Exec, the insert helpers, ExecDDL, ...) log its statement instead of executing it
+// and report one affected row. Reads still run normally. Change listeners are not notified of dry-run writes.
+func SetDryRun(enabled bool) {
+	dryRun.Store(enabled)
+}
+
+// The result of a statement skipped in dry-run mode.
+type dryRunResult struct{}
+
+func (dryRunResult) LastInsertId() (int64, error) { return 0, nil }
+func (dryRunResult) RowsAffected() (int64, error) { return 1, nil }
+
+// Logs the statement and returns a synthetic result when dry-run mode is enabled.
+// The statement is only rendered for the log in dry-run mode.
+func skipDryRun(query string, args []interface{}) (sql.Result, bool) {
+	if !dryRun.Load() {
+		return nil, false
+	}
+
+	log.Printf("[dry run] %s\n", queryToString(query, args))
+	return dryRunResult{}, true
+}
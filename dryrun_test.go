@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDryRunSkipsWrites(t *testing.T) {
+	SetDryRun(true)
+	defer SetDryRun(false)
+
+	pool := openFakeDB(nil)
+	defer pool.Close()
+
+	stmt, err := pool.Prepare("DELETE FROM t WHERE id = ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+
+	// The fake driver reports no affected rows, a skipped statement one
+	res, err := ExecPrepared(stmt, "DELETE FROM t WHERE id = ?", []interface{}{1})
+	if err != nil {
+		t.Fatalf("ExecPrepared error = %v", err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Errorf("ExecPrepared ran the statement in dry-run mode")
+	}
+
+	res, err = ExecIn(context.Background(), pool, "DELETE FROM t", nil)
+	if err != nil {
+		t.Fatalf("ExecIn error = %v", err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Errorf("ExecIn ran the statement in dry-run mode")
+	}
+}
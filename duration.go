@@ -0,0 +1,90 @@
+package db
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// A time.Duration stored in a MySQL TIME column as [-]HH:MM:SS[.ffffff]. NULL scans as 0.
+//
+// Plain time.Duration fields can be read from TIME columns with the duration tag option instead, e.g. `db:"elapsed,duration"`.
+type DurationColumn time.Duration
+
+func (d *DurationColumn) Scan(src interface{}) error {
+	if src == nil {
+		*d = 0
+		return nil
+	}
+
+	parsed, err := parseTimeColumn(src)
+	if err != nil {
+		return err
+	}
+	*d = DurationColumn(parsed)
+	return nil
+}
+
+// Writes the duration in the TIME format.
+func (d DurationColumn) Value() (driver.Value, error) {
+	return formatTimeColumn(time.Duration(d)), nil
+}
+
+func (d DurationColumn) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+// Parses a TIME value, [-][H]HH:MM:SS[.ffffff], as returned by the driver.
+func parseTimeColumn(src interface{}) (time.Duration, error) {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return 0, fmt.Errorf("db: cannot scan %T into a duration", src)
+	}
+
+	neg := strings.HasPrefix(s, "-")
+	parts := strings.Split(strings.TrimPrefix(s, "-"), ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("db: invalid TIME value %q", s)
+	}
+
+	hours, err1 := strconv.ParseInt(parts[0], 10, 64)
+	minutes, err2 := strconv.ParseInt(parts[1], 10, 64)
+	seconds, err3 := strconv.ParseFloat(parts[2], 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, fmt.Errorf("db: invalid TIME value %q", s)
+	}
+
+	d := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second)).Round(time.Microsecond)
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+func formatTimeColumn(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+
+	h := d / time.Hour
+	m := (d % time.Hour) / time.Minute
+	s := (d % time.Minute) / time.Second
+	us := (d % time.Second) / time.Microsecond
+
+	if us == 0 {
+		return fmt.Sprintf("%s%02d:%02d:%02d", sign, h, m, s)
+	}
+	return fmt.Sprintf("%s%02d:%02d:%02d.%06d", sign, h, m, s, us)
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// The values allowed for a string-backed Go type mapped to a MySQL ENUM column.
+type enumInfo struct {
+	name   string
+	values map[string]struct{}
+}
+
+var (
+	enumMu sync.RWMutex
+	enums  = map[reflect.Type]enumInfo{}
+)
+
+// Registers the values a string-backed type may hold. Struct fields of type T (or *T) are then
+// validated when scanned and an unknown value fails the scan instead of being accepted silently.
+// typeName is only used in error messages.
+func RegisterEnum[T ~string](typeName string, values []T) {
+	info := enumInfo{name: typeName, values: make(map[string]struct{}, len(values))}
+	for _, v := range values {
+		info.values[string(v)] = struct{}{}
+	}
+
+	enumMu.Lock()
+	defer enumMu.Unlock()
+
+	enums[reflect.TypeOf((*T)(nil)).Elem()] = info
+}
+
+// Converts a scanned value to the enum type T, failing if T is not registered or the value is not one of its values.
+func EnumScanner[T ~string](src interface{}) (T, error) {
+	s, err := enumValue(reflect.TypeOf((*T)(nil)).Elem(), src)
+	return T(s), err
+}
+
+// Reports whether t, or the type t points to, has been registered with RegisterEnum.
+func isEnumType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	enumMu.RLock()
+	defer enumMu.RUnlock()
+
+	_, ok := enums[t]
+	return ok
+}
+
+func enumValue(t reflect.Type, src interface{}) (string, error) {
+	enumMu.RLock()
+	info, ok := enums[t]
+	enumMu.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("db: %s is not a registered enum", t)
+	}
+
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return "", fmt.Errorf("db: cannot scan %T into enum %s", src, info.name)
+	}
+
+	if _, ok := info.values[s]; !ok {
+		return "", fmt.Errorf("db: unknown %s value %q", info.name, s)
+	}
+	return s, nil
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Returned when a query expected to find a row did not. It wraps sql.ErrNoRows,
+// so errors.Is(err, sql.ErrNoRows) holds as well.
+var ErrNoRows = fmt.Errorf("db: no rows in result set: %w", sql.ErrNoRows)
+
+// Returned by the methods of a NamedDB after it has been closed.
+var ErrDBClosed = errors.New("db: database is closed")
+
+// Same as ErrNoRows, for callers used to the name.
+var ErrNotFound = ErrNoRows
+
+// Classes of MySQL errors, matched with errors.Is on the errors returned by the query functions
+// or by ClassifyError. The original *mysql.MySQLError stays reachable with errors.As.
+var (
+	ErrDuplicateKey    = errors.New("db: duplicate key")     // 1062, 1586
+	ErrDeadlock        = errors.New("db: deadlock")          // 1213
+	ErrLockWaitTimeout = errors.New("db: lock wait timeout") // 1205
+)
+
+var mysqlErrorClasses = map[uint16]error{
+	1062: ErrDuplicateKey,
+	1586: ErrDuplicateKey,
+	1213: ErrDeadlock,
+	1205: ErrLockWaitTimeout,
+}
+
+// Wraps err with the class matching its MySQL error number, so errors.Is(err, ErrDuplicateKey) and the like hold.
+// sql.ErrNoRows is mapped to ErrNotFound. Other errors, nil included, are returned as is.
+func ClassifyError(err error) error {
+	if err == nil || errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return err
+	}
+	class, ok := mysqlErrorClasses[mysqlErr.Number]
+	if !ok || errors.Is(err, class) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", class, err)
+}
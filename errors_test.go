@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestRowErrorsAreClassified(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+	pool := sql.OpenDB(&fakeConnector{
+		columns: []string{"id"},
+		rows:    [][]driver.Value{{int64(1)}},
+		rowsErr: deadlock,
+	})
+	useSharedPool(t, pool)
+
+	_, err := AllE[struct{ ID int64 }]("SELECT id FROM t", nil)
+	if !errors.Is(err, ErrDeadlock) {
+		t.Errorf("AllE error = %v, want ErrDeadlock", err)
+	}
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != 1213 {
+		t.Errorf("AllE error = %v, want the MySQL error to stay reachable", err)
+	}
+
+	if _, err := ColumnSlice[int64]("SELECT id FROM t", nil); !errors.Is(err, ErrDeadlock) {
+		t.Errorf("ColumnSlice error = %v, want ErrDeadlock", err)
+	}
+}
+
+func TestColumnWithoutRowsIsNotFound(t *testing.T) {
+	pool := openFakeDB([]string{"n"})
+	useSharedPool(t, pool)
+
+	var n int64
+	if err := Column("SELECT n FROM t", nil, &n); !errors.Is(err, ErrNotFound) || !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Column error = %v, want ErrNotFound", err)
+	}
+	if err := ColumnIn(context.Background(), pool, "SELECT n FROM t", nil, &n); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ColumnIn error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Expands the placeholder of every slice argument into one placeholder per element and flattens the
+// elements into args, so "id IN (?)" with []int64{1, 2, 3} becomes "id IN (?, ?, ?)" with 1, 2, 3.
+// []byte and driver.Valuer arguments are single values and left alone. An empty slice is an error,
+// as "IN ()" is not valid SQL.
+func expandSliceArgs(query string, args []interface{}) (string, []interface{}, error) {
+	if !hasSliceArg(args) {
+		return query, args, nil
+	}
+
+	var (
+		b        strings.Builder
+		expanded []interface{}
+		n        int
+	)
+	for i := 0; i < len(query); i++ {
+		if end, ok, err := literalEnd(query, i); err != nil {
+			return "", nil, err
+		} else if ok {
+			b.WriteString(query[i : end+1])
+			i = end
+			continue
+		}
+
+		if query[i] != '?' {
+			b.WriteByte(query[i])
+			continue
+		}
+
+		if n >= len(args) {
+			return "", nil, fmt.Errorf("db: query has more placeholders than the %d args", len(args))
+		}
+		arg := args[n]
+		n++
+
+		if !isSliceArg(arg) {
+			b.WriteByte('?')
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		rv := reflect.ValueOf(arg)
+		if rv.Len() == 0 {
+			return "", nil, fmt.Errorf("db: empty slice for placeholder %d", n)
+		}
+		b.WriteString(placeholders(rv.Len()))
+		for j := 0; j < rv.Len(); j++ {
+			expanded = append(expanded, rv.Index(j).Interface())
+		}
+	}
+
+	if n != len(args) {
+		return "", nil, fmt.Errorf("db: query has %d placeholders for %d args", n, len(args))
+	}
+	return b.String(), expanded, nil
+}
+
+func hasSliceArg(args []interface{}) bool {
+	for _, arg := range args {
+		if isSliceArg(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSliceArg(arg interface{}) bool {
+	if _, ok := arg.(driver.Valuer); ok {
+		return false
+	}
+	t := reflect.TypeOf(arg)
+	return t != nil && t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
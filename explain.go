@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Returns the execution plan of the query as produced by `EXPLAIN FORMAT=JSON` (MySQL 5.6+).
+// The query itself is not executed.
+func ExplainQueryJSON(ctx context.Context, query string, args []interface{}) (json.RawMessage, error) {
+	var plan []byte
+	if err := ColumnCtx(internalQuery(ctx), "EXPLAIN FORMAT=JSON "+applyQueryFilter(ctx, query), args, &plan); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(plan), nil
+}
+
+// Returns the execution plan of the query as produced by `EXPLAIN FORMAT=TREE` (MySQL 8.0.16+).
+// The query itself is not executed.
+func ExplainQueryTree(ctx context.Context, query string, args []interface{}) (string, error) {
+	var plan string
+	err := ColumnCtx(internalQuery(ctx), "EXPLAIN FORMAT=TREE "+applyQueryFilter(ctx, query), args, &plan)
+	return plan, err
+}
+
+// Bits of the float64 cost above which SELECT plans are logged, 0 when disabled.
+var explainCostThreshold atomic.Uint64
+
+// Runs `EXPLAIN FORMAT=JSON` before every SELECT and logs the plan when its estimated query_cost
+// exceeds cost, to catch accidental full table scans before production. The EXPLAIN costs an
+// extra round trip per query, so this is meant for development and staging. 0 disables it.
+func SetExplainCostThreshold(cost float64) {
+	explainCostThreshold.Store(math.Float64bits(cost))
+}
+
+// Logs the plan of query when it is a SELECT, possibly behind comments such as those of WithComment,
+// whose estimated cost exceeds the threshold.
+// Failing to explain the query is not an error, the query itself will report it.
+func logCostlyPlan(ctx context.Context, db Queryer, query string, args []interface{}) {
+	threshold := math.Float64frombits(explainCostThreshold.Load())
+	if threshold <= 0 || !strings.HasPrefix(strings.ToUpper(trimLeadingComments(query)), "SELECT") {
+		return
+	}
+
+	var plan []byte
+	if err := db.QueryRowContext(ctx, "EXPLAIN FORMAT=JSON "+query, args...).Scan(&plan); err != nil {
+		return
+	}
+
+	var parsed struct {
+		QueryBlock struct {
+			CostInfo struct {
+				QueryCost json.Number `json:"query_cost"`
+			} `json:"cost_info"`
+		} `json:"query_block"`
+	}
+	if err := json.Unmarshal(plan, &parsed); err != nil {
+		return
+	}
+
+	cost, err := strconv.ParseFloat(parsed.QueryBlock.CostInfo.QueryCost.String(), 64)
+	if err != nil || cost <= threshold {
+		return
+	}
+	log.Printf("[cost %.2f > %.2f] %s\n%s\n", cost, threshold, queryToString(query, args), plan)
+}
+
+var (
+	explainPatternMu sync.RWMutex
+	explainPattern   *regexp.Regexp
+)
+
+// Logs the `EXPLAIN FORMAT=TRADITIONAL` plan of every query and statement matching pattern before running it,
+// e.g. regexp.MustCompile(`\border_items\b`) to watch the queries touching a table during development.
+// Nothing is explained while logging is disabled. Pass nil to stop.
+func SetExplainPattern(pattern *regexp.Regexp) {
+	explainPatternMu.Lock()
+	defer explainPatternMu.Unlock()
+
+	explainPattern = pattern
+}
+
+// Logs the plan of query when it matches the pattern set with SetExplainPattern.
+func logMatchingPlan(ctx context.Context, db Queryer, query string, args []interface{}) {
+	explainPatternMu.RLock()
+	pattern := explainPattern
+	explainPatternMu.RUnlock()
+
+	if !GetIsLogging() || pattern == nil || !pattern.MatchString(query) {
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, "EXPLAIN FORMAT=TRADITIONAL "+query, args...)
+	if err != nil {
+		log.Printf("[explain] %s: %v\n", queryToString(query, args), err)
+		return
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return
+	}
+	values := make([]interface{}, len(cols))
+	scans := make([]interface{}, len(cols))
+	for i := range scans {
+		scans[i] = &values[i]
+	}
+
+	var b strings.Builder
+	for rows.Next() {
+		if err := rows.Scan(scans...); err != nil {
+			return
+		}
+		for i, col := range cols {
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			value := values[i]
+			if v, ok := value.([]byte); ok {
+				value = string(v)
+			}
+			fmt.Fprintf(&b, "%s=%v", col, value)
+		}
+		b.WriteString("\n")
+	}
+	log.Printf("[explain] %s\n%s", queryToString(query, args), b.String())
+}
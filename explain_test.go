@@ -0,0 +1,29 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestLogCostlyPlanSkipsLeadingComments(t *testing.T) {
+	SetExplainCostThreshold(10)
+	defer SetExplainCostThreshold(0)
+
+	var buf bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&buf)
+
+	pool := openFakeDB([]string{"EXPLAIN"}, []driver.Value{[]byte(`{"query_block":{"cost_info":{"query_cost":"123.5"}}}`)})
+	defer pool.Close()
+
+	ctx := WithQueryOptions(context.Background(), WithComment("app=test"))
+	logCostlyPlan(ctx, pool, applyQueryOptions(ctx, "SELECT * FROM t"), nil)
+
+	if !strings.Contains(buf.String(), "[cost 123.50 > 10.00]") {
+		t.Errorf("costly plan of a commented SELECT not logged, got %q", buf.String())
+	}
+}
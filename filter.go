@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	queryFilterMu sync.RWMutex
+	queryFilter   func(query string) string
+
+	filterStmtRegex  = regexp.MustCompile(`(?is)^\s*(SELECT|UPDATE|DELETE)\b`)
+	filterWhereRegex = regexp.MustCompile(`(?i)\bWHERE\b`)
+	filterTailRegex  = regexp.MustCompile(`(?i)\b(GROUP\s+BY|HAVING|ORDER\s+BY|LIMIT|FOR\s+UPDATE|FOR\s+SHARE|LOCK\s+IN)\b`)
+)
+
+// Installs a function rewriting every query and statement before it is executed, e.g. to add a
+// tenant or soft-delete condition. Pass nil to remove it.
+//
+// DANGEROUS: the filter sees and changes every statement the package runs, including the ones
+// built by the helpers, and a mistake silently changes what every query reads or writes.
+// Only the package's own bookkeeping is left alone: the information_schema lookups, migrations
+// and the _migrations table, and the EXPLAIN wrapped around an already filtered query.
+func SetGlobalQueryFilter(filter func(query string) string) {
+	queryFilterMu.Lock()
+	defer queryFilterMu.Unlock()
+
+	queryFilter = filter
+}
+
+type internalKey struct{}
+
+// Marks the queries made with the returned context as the package's own, which the global filter leaves alone.
+func internalQuery(ctx context.Context) context.Context {
+	return context.WithValue(ctx, internalKey{}, true)
+}
+
+func applyQueryFilter(ctx context.Context, query string) string {
+	if ctx.Value(internalKey{}) != nil {
+		return query
+	}
+
+	queryFilterMu.RLock()
+	filter := queryFilter
+	queryFilterMu.RUnlock()
+
+	if filter == nil {
+		return query
+	}
+	return filter(query)
+}
+
+// Returns a filter for SetGlobalQueryFilter adding `col = value` (or `col IS NULL` for nil values)
+// to the WHERE clause of every SELECT, UPDATE and DELETE. Values are inlined as literals.
+//
+// The clause is found textually, so the filter only suits simple single-table statements:
+// subqueries, joins and UNIONs are not rewritten correctly.
+func BuildGlobalFilter(conditions map[string]interface{}) func(string) string {
+	cols := make([]string, 0, len(conditions))
+	for col := range conditions {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	conds := make([]string, len(cols))
+	for i, col := range cols {
+		if value := conditions[col]; value == nil {
+			conds[i] = quoteIdent(col) + " IS NULL"
+		} else {
+			conds[i] = quoteIdent(col) + " = " + sqlLiteral(value)
+		}
+	}
+	cond := strings.Join(conds, " AND ")
+
+	return func(query string) string {
+		if cond == "" || !filterStmtRegex.MatchString(query) {
+			return query
+		}
+
+		if loc := filterWhereRegex.FindStringIndex(query); loc != nil {
+			rest, tail := query[loc[1]:], ""
+			if t := filterTailRegex.FindStringIndex(rest); t != nil {
+				rest, tail = rest[:t[0]], " "+rest[t[0]:]
+			}
+			return query[:loc[1]] + " " + cond + " AND (" + strings.TrimSpace(rest) + ")" + tail
+		}
+
+		if tail := filterTailRegex.FindStringIndex(query); tail != nil {
+			return query[:tail[0]] + "WHERE " + cond + " " + query[tail[0]:]
+		}
+		return strings.TrimRight(query, "; \t\n") + " WHERE " + cond
+	}
+}
+
+// Renders value as a MySQL literal.
+func sqlLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprintf("%v", v)
+	case time.Time:
+		return quoteLiteral(v.Format("2006-01-02 15:04:05.999999"))
+	case []byte:
+		return quoteLiteral(string(v))
+	}
+	return quoteLiteral(fmt.Sprintf("%v", value))
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGlobalQueryFilterSkipsInternalQueries(t *testing.T) {
+	pool, c := openFakeConnector([]string{"n"}, []driver.Value{int64(1)})
+	useSharedPool(t, pool)
+
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	c.queryErr = func(query string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		queries = append(queries, query)
+		return nil
+	}
+
+	SetGlobalQueryFilter(func(query string) string {
+		return query + " /* filtered */"
+	})
+	t.Cleanup(func() { SetGlobalQueryFilter(nil) })
+
+	if _, err := TableExists(context.Background(), "users"); err != nil {
+		t.Fatalf("TableExists error = %v", err)
+	}
+	if _, err := Exec("DELETE FROM users", nil); err != nil {
+		t.Fatalf("Exec error = %v", err)
+	}
+
+	if len(queries) == 0 {
+		t.Fatal("TableExists ran no query")
+	}
+	for _, query := range queries {
+		if strings.Contains(query, "filtered") {
+			t.Errorf("internal query %q went through the filter", query)
+		}
+	}
+	if execs := c.statements(); len(execs) != 1 || !strings.HasSuffix(execs[0], "/* filtered */") {
+		t.Errorf("statements = %q, want the DELETE filtered", execs)
+	}
+}
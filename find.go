@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Fetches the row of T whose primary key equals id, or returns ErrNoRows.
+//
+// The table name is the snake_case plural of the type name, e.g. UserProfile reads user_profiles.
+// The primary key is the field tagged `db:"name,pk"`, or the field named ID.
+func Find[T any](id interface{}) (*T, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	pk, ok := primaryKey(t)
+	if !ok {
+		return nil, fmt.Errorf("db: %s has no primary key field, tag one with `db:\"name,pk\"`", t)
+	}
+	return findOne[T](t, pk, id)
+}
+
+// Fetches the first row of T whose column col equals val, or returns ErrNoRows.
+// The table name is inferred as in Find.
+func FindBy[T any](col string, val interface{}) (*T, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	if !identRegex.MatchString(col) {
+		return nil, fmt.Errorf("db: invalid identifier %q", col)
+	}
+	return findOne[T](t, col, val)
+}
+
+func findOne[T any](t reflect.Type, col string, val interface{}) (*T, error) {
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s = ? LIMIT 1", quoteIdent(tableName(t)), quoteIdent(col))
+
+	db, err := getPool()
+	if err != nil {
+		return nil, err
+	}
+
+	res, found, err := oneFrom[T](context.Background(), db, query, []interface{}{val})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNoRows
+	}
+	return &res, nil
+}
+
+// Returns the table name inferred from a struct type: its snake_case name, pluralized.
+func tableName(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	name := toSnake(t.Name())
+	switch {
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "z"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	}
+	return name + "s"
+}
+
+// Returns the primary key column of a struct type: the field tagged `db:"name,pk"`, else the field named ID.
+func primaryKey(t reflect.Type) (string, bool) {
+	var tagged, byName string
+	eachColumn(t, nil, func(i int, field reflect.StructField, col string) {
+		if tagged == "" && hasTagOption(field, "pk") {
+			tagged = col
+		}
+		if field.Name == "ID" {
+			byName = col
+		}
+	})
+
+	if tagged != "" {
+		return tagged, true
+	}
+	return byName, byName != ""
+}
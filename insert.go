@@ -0,0 +1,341 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Replace writes row into table using REPLACE INTO.
+//
+// Unlike INSERT ... ON DUPLICATE KEY UPDATE, which updates the existing row in place,
+// REPLACE deletes the conflicting row and inserts a new one. Columns not present in row
+// fall back to their defaults, a fresh auto-increment value may be assigned, and any
+// ON DELETE foreign key actions referencing the old row are triggered.
+func Replace[T any](table string, row T) (sql.Result, error) {
+	return BulkReplace(table, []T{row})
+}
+
+// BulkReplace writes all rows into table with a single multi-row REPLACE INTO statement.
+// See Replace for how REPLACE differs from an upsert.
+func BulkReplace[T any](table string, rows []T) (sql.Result, error) {
+	query, args, err := buildInsert("REPLACE", table, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := getPool(false)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := execFrom(context.Background(), db, query, args)
+	if err == nil {
+		for _, row := range rows {
+			publishChange(table, "REPLACE", row)
+		}
+	}
+	return res, err
+}
+
+// Insert writes row into table with INSERT INTO, the columns being named from the `db` and `json` tags
+// as in ScanStruct. A zero primary key (the field tagged `db:"name,pk"` or named ID) is left out so MySQL
+// assigns the auto-increment value, returned by LastInsertId.
+func Insert[T any](table string, row T) (sql.Result, error) {
+	cols, args, err := structColumns(row)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(row))
+	if pk, ok := primaryKey(rv.Type()); ok {
+		for i, col := range cols {
+			if col == pk && (args[i] == nil || reflect.ValueOf(args[i]).IsZero()) {
+				cols = append(cols[:i:i], cols[i+1:]...)
+				args = append(args[:i:i], args[i+1:]...)
+				break
+			}
+		}
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("db: %T has no columns", row)
+	}
+
+	quoted := make([]string, len(cols))
+	for i, col := range cols {
+		quoted[i] = quoteIdent(col)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdent(table), strings.Join(quoted, ", "), placeholders(len(args)))
+	db, err := getPool(false)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := execFrom(context.Background(), db, query, args)
+	if err == nil {
+		publishChange(table, "INSERT", row)
+	}
+	return res, err
+}
+
+// Customises BulkInsert.
+type InsertOption func(*insertOptions)
+
+type insertOptions struct {
+	batchSize int
+}
+
+// Splits the insert into statements of at most n rows run in a single transaction, as InsertMany does.
+func WithBatchSize(n int) InsertOption {
+	return func(o *insertOptions) {
+		o.batchSize = n
+	}
+}
+
+// BulkInsert writes all rows into table with a multi-row INSERT INTO statement,
+// or several of them in a transaction when WithBatchSize is used.
+//
+// LastInsertId is not available on the result of a batched insert.
+func BulkInsert[T any](table string, rows []T, opts ...InsertOption) (sql.Result, error) {
+	var o insertOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.batchSize > 0 && len(rows) > o.batchSize {
+		n, err := InsertMany(table, rows, o.batchSize)
+		if err != nil {
+			return nil, err
+		}
+		return batchResult(n), nil
+	}
+
+	query, args, err := buildInsert("INSERT", table, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := getPool(false)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := execFrom(context.Background(), db, query, args)
+	if err == nil {
+		for _, row := range rows {
+			publishChange(table, "INSERT", row)
+		}
+	}
+	return res, err
+}
+
+// Same as InsertMany, but a batchSize <= 0 is an error instead of the default chunk size.
+func GroupInsert[T any](table string, rows []T, batchSize int) (totalInserted int64, err error) {
+	if batchSize <= 0 {
+		return 0, fmt.Errorf("db: invalid batch size %d", batchSize)
+	}
+	return InsertMany(table, rows, batchSize)
+}
+
+const defaultInsertChunkSize = 1000
+
+// InsertMany inserts rows into table with multi-row INSERTs of chunkSize rows (1000 when chunkSize <= 0)
+// and returns the number of inserted rows. The chunks run in a single transaction, so either every row
+// is inserted or none.
+//
+// Chunks are counted in rows, not bytes: keep chunkSize small enough for wide rows to stay below
+// max_allowed_packet.
+func InsertMany[T any](table string, rows []T, chunkSize int) (int64, error) {
+	if chunkSize <= 0 {
+		chunkSize = defaultInsertChunkSize
+	}
+	return insertInBatches(context.Background(), table, rows, chunkSize)
+}
+
+func insertInBatches[T any](ctx context.Context, table string, rows []T, batchSize int) (int64, error) {
+	if len(rows) == 0 {
+		return 0, errors.New("db: no rows to insert")
+	}
+
+	db, err := getPool(false)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for start := 0; start < len(rows); start += batchSize {
+		end := start + batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		query, args, err := buildInsert("INSERT", table, rows[start:end])
+		if err == nil {
+			var res sql.Result
+			if res, err = execFrom(ctx, tx, query, args); err == nil {
+				var n int64
+				n, err = res.RowsAffected()
+				total += n
+			}
+		}
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("db: insert rows %d-%d: %w", start, end-1, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, ClassifyError(err)
+	}
+
+	for _, row := range rows {
+		publishChange(table, "INSERT", row)
+	}
+	return total, nil
+}
+
+// The combined result of the statements of a batched insert.
+type batchResult int64
+
+func (r batchResult) LastInsertId() (int64, error) {
+	return 0, errors.New("db: LastInsertId is not available for batched inserts")
+}
+
+func (r batchResult) RowsAffected() (int64, error) {
+	return int64(r), nil
+}
+
+// InsertSelect copies rows with `INSERT INTO dstTable (dstCols...) <selectQuery>` on the write pool,
+// without fetching them. The select is first run wrapped in a LIMIT 0 subquery to check that it
+// returns exactly one column per destination column. A select the server cannot wrap, e.g. one
+// returning two columns of the same name, is not checked.
+func InsertSelect(dstTable string, dstCols []string, selectQuery string, selectArgs []interface{}) (sql.Result, error) {
+	if len(dstCols) == 0 {
+		return nil, errors.New("db: no destination columns")
+	}
+
+	cols := make([]string, len(dstCols))
+	for i, col := range dstCols {
+		if !identRegex.MatchString(col) {
+			return nil, fmt.Errorf("db: invalid identifier %q", col)
+		}
+		cols[i] = quoteIdent(col)
+	}
+
+	ctx := context.Background()
+	db, err := getPool(false)
+	if err != nil {
+		return nil, err
+	}
+
+	srcCols, err := selectColumns(ctx, db, selectQuery, selectArgs)
+	var mysqlErr *mysql.MySQLError
+	switch {
+	case errors.As(err, &mysqlErr):
+		// Left to the INSERT, which reports any error of the select itself
+	case err != nil:
+		return nil, err
+	case len(srcCols) != len(dstCols):
+		return nil, fmt.Errorf("db: select returns %d columns for %d destination columns", len(srcCols), len(dstCols))
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) %s", quoteIdent(dstTable), strings.Join(cols, ", "), selectQuery)
+	res, err := execFrom(ctx, db, query, selectArgs)
+	if err == nil {
+		publishQueryChange(query, selectArgs)
+	}
+	return res, err
+}
+
+// Returns the columns of selectQuery by running it wrapped in a LIMIT 0 subquery.
+func selectColumns(ctx context.Context, db Queryer, selectQuery string, selectArgs []interface{}) ([]string, error) {
+	rows, err := queryRows(ctx, db, "SELECT * FROM ("+selectQuery+") AS `_src` LIMIT 0", selectArgs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return rows.Columns()
+}
+
+// buildInsert generates `<verb> INTO table (cols...) VALUES (...), (...)` for rows.
+func buildInsert[T any](verb string, table string, rows []T) (string, []interface{}, error) {
+	if len(rows) == 0 {
+		return "", nil, fmt.Errorf("db: no rows to %s", strings.ToLower(verb))
+	}
+
+	var (
+		cols   []string
+		args   []interface{}
+		values []string
+	)
+	for _, row := range rows {
+		rowCols, rowArgs, err := structColumns(row)
+		if err != nil {
+			return "", nil, err
+		}
+		if cols == nil {
+			cols = rowCols
+		}
+
+		values = append(values, "("+placeholders(len(rowCols))+")")
+		args = append(args, rowArgs...)
+	}
+
+	if len(cols) == 0 {
+		return "", nil, fmt.Errorf("db: %T has no columns", rows[0])
+	}
+
+	for i, col := range cols {
+		cols[i] = quoteIdent(col)
+	}
+
+	query := fmt.Sprintf("%s INTO %s (%s) VALUES %s", verb, quoteIdent(table), strings.Join(cols, ", "), strings.Join(values, ", "))
+	return query, args, nil
+}
+
+// structColumns returns the column names and values of the exported fields of row.
+func structColumns(row interface{}) ([]string, []interface{}, error) {
+	rv := reflect.Indirect(reflect.ValueOf(row))
+	if rv.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("db: expected a struct, got %T", row)
+	}
+
+	var (
+		cols []string
+		args []interface{}
+	)
+	eachColumn(rv.Type(), nil, func(i int, field reflect.StructField, col string) {
+		cols = append(cols, col)
+		args = append(args, rv.Field(i).Interface())
+	})
+
+	return cols, args, nil
+}
+
+func placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.Repeat("?, ", n-1) + "?"
+}
+
+// quoteIdent wraps a (possibly schema-qualified) identifier in backticks.
+func quoteIdent(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = "`" + strings.ReplaceAll(strings.Trim(part, "`"), "`", "``") + "`"
+	}
+	return strings.Join(parts, ".")
+}
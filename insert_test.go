@@ -0,0 +1,53 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestInsertSelectSkipsCheckWhenSelectCannotBeWrapped(t *testing.T) {
+	fake := &fakeConnector{
+		columns: []string{"id", "id"},
+		queryErr: func(query string) error {
+			if strings.Contains(query, "`_src`") {
+				return &mysql.MySQLError{Number: 1060, Message: "Duplicate column name 'id'"}
+			}
+			return nil
+		},
+	}
+	useSharedPool(t, sql.OpenDB(fake))
+
+	_, err := InsertSelect("archive", []string{"user_id", "order_id"}, "SELECT u.id, o.id FROM users u JOIN orders o ON o.user_id = u.id", nil)
+	if err != nil {
+		t.Fatalf("InsertSelect error = %v", err)
+	}
+	if stmts := fake.statements(); len(stmts) != 1 || !strings.HasPrefix(stmts[0], "INSERT INTO `archive`") {
+		t.Errorf("statements = %q, want the INSERT ... SELECT", stmts)
+	}
+}
+
+func TestBatchedInsertsShareInsertMany(t *testing.T) {
+	pool, fake := openFakeConnector(nil)
+	useSharedPool(t, pool)
+
+	type user struct {
+		ID int64
+	}
+	rows := []user{{1}, {2}, {3}}
+
+	if _, err := GroupInsert("users", rows, 0); err == nil {
+		t.Error("GroupInsert with batch size 0 succeeded, want an error")
+	}
+	if _, err := GroupInsert("users", rows, 2); err != nil {
+		t.Fatalf("GroupInsert error = %v", err)
+	}
+	if _, err := BulkInsert("users", rows, WithBatchSize(2)); err != nil {
+		t.Fatalf("BulkInsert error = %v", err)
+	}
+	if stmts := fake.statements(); len(stmts) != 4 {
+		t.Errorf("statements = %q, want 2 chunks of 2 and 1 rows per insert", stmts)
+	}
+}
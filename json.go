@@ -0,0 +1,102 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// Executes the query and encodes the rows as a JSON array of objects keyed by column name,
+// without scanning them into structs or maps first.
+//
+// Numeric columns are written as JSON numbers, JSON columns are embedded as is,
+// NULL becomes null and every other value is written as a string.
+func AllJSON(ctx context.Context, query string, args []interface{}) ([]byte, error) {
+	defer timer(query, args)()
+
+	db, err := dbFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := queryRows(ctx, db, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([][]byte, len(types))
+	kinds := make([]string, len(types))
+	for i, t := range types {
+		if keys[i], err = json.Marshal(t.Name()); err != nil {
+			return nil, err
+		}
+		kinds[i] = strings.TrimPrefix(strings.ToUpper(t.DatabaseTypeName()), "UNSIGNED ")
+	}
+
+	values := make([]interface{}, len(types))
+	scans := make([]interface{}, len(types))
+	for i := range scans {
+		scans[i] = &values[i]
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for n := 0; rows.Next(); n++ {
+		if err := rows.Scan(scans...); err != nil {
+			return nil, ClassifyError(err)
+		}
+
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		for i, value := range values {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(keys[i])
+			buf.WriteByte(':')
+			if err := writeJSONValue(&buf, kinds[i], value); err != nil {
+				return nil, err
+			}
+		}
+		buf.WriteByte('}')
+	}
+	if err := rows.Err(); err != nil {
+		return nil, ClassifyError(err)
+	}
+	buf.WriteByte(']')
+
+	return buf.Bytes(), nil
+}
+
+func writeJSONValue(buf *bytes.Buffer, kind string, value interface{}) error {
+	if b, ok := value.([]byte); ok {
+		switch kind {
+		case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "YEAR", "DECIMAL", "FLOAT", "DOUBLE":
+			// MySQL's text representation of a number is a valid JSON number
+			buf.Write(b)
+			return nil
+		case "JSON":
+			if json.Valid(b) {
+				buf.Write(b)
+				return nil
+			}
+		}
+		value = string(b)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	buf.Write(encoded)
+	return nil
+}
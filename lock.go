@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The access a LOCK TABLES statement grants the locking connection.
+type LockMode string
+
+const (
+	LockRead  LockMode = "READ"
+	LockWrite LockMode = "WRITE"
+)
+
+// A table to lock and how.
+type LockTableSpec struct {
+	Table string
+	Mode  LockMode
+}
+
+// Runs `LOCK TABLES t1 WRITE, t2 READ, ...` and returns the function running UNLOCK TABLES.
+//
+// Table locks belong to the connection, so ctx MUST come from WithConnectionAffinity and every query made
+// while the tables are locked must use it. The connection can only access the locked tables until unlocked.
+func LockTables(ctx context.Context, tables ...LockTableSpec) (func() error, error) {
+	conn, ok := pinnedConn(ctx)
+	if !ok {
+		return nil, errors.New("db: LockTables requires a context from WithConnectionAffinity")
+	}
+	if len(tables) == 0 {
+		return nil, errors.New("db: no tables to lock")
+	}
+
+	specs := make([]string, len(tables))
+	for i, spec := range tables {
+		if !identRegex.MatchString(spec.Table) {
+			return nil, fmt.Errorf("db: invalid identifier %q", spec.Table)
+		}
+		if spec.Mode != LockRead && spec.Mode != LockWrite {
+			return nil, fmt.Errorf("db: invalid lock mode %q", spec.Mode)
+		}
+		specs[i] = quoteIdent(spec.Table) + " " + string(spec.Mode)
+	}
+
+	if _, err := execFrom(ctx, conn, "LOCK TABLES "+strings.Join(specs, ", "), nil); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		_, err := execFrom(context.Background(), conn, "UNLOCK TABLES", nil)
+		return err
+	}, nil
+}
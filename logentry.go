@@ -0,0 +1,88 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A query and its arguments as written to a structured log, sensitive arguments already redacted.
+type QueryLogEntry struct {
+	Query string
+	Args  []interface{}
+}
+
+// Returns the log entry of a query, with the arguments matched by RegisterSensitiveParam
+// and RegisterSensitiveTable redacted. Comments are stripped from the query, except optimizer hints
+// and executable comments, and placeholders inside them or inside quoted strings are not counted.
+func NewQueryLogEntry(query string, args []interface{}) QueryLogEntry {
+	query = stripComments(query)
+	return QueryLogEntry{Query: query, Args: redactArgs(query, args)}
+}
+
+// Replaces the comments of query by a space, keeping `/*+ ... */` and `/*! ... */` as SanitizeSQL does.
+func stripComments(query string) string {
+	var b strings.Builder
+	for i := 0; i < len(query); i++ {
+		end, ok, err := literalEnd(query, i)
+		if err != nil {
+			b.WriteString(query[i:])
+			break
+		}
+		if !ok {
+			b.WriteByte(query[i])
+			continue
+		}
+
+		switch c := query[i]; {
+		case c == '\'' || c == '"' || c == '`', strings.HasPrefix(query[i:], "/*+"), strings.HasPrefix(query[i:], "/*!"):
+			b.WriteString(query[i : end+1])
+		default:
+			b.WriteByte(' ')
+		}
+		i = end
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Encodes the entry as {"query": "...", "args": [...]}. Numbers and booleans keep their JSON type,
+// nil becomes null, []byte is written as a string and times use RFC 3339.
+func (e QueryLogEntry) MarshalJSON() ([]byte, error) {
+	args := make([]interface{}, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = logValue(arg)
+	}
+
+	return json.Marshal(struct {
+		Query string        `json:"query"`
+		Args  []interface{} `json:"args"`
+	}{e.Query, args})
+}
+
+// Converts an argument to a value encoding/json writes with the matching JSON type.
+func logValue(arg interface{}) interface{} {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return fmt.Sprintf("%v", arg)
+		}
+		arg = v
+	}
+
+	switch v := arg.(type) {
+	case nil, bool, string,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprintf("%v", arg)
+}
@@ -0,0 +1,20 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewQueryLogEntry(t *testing.T) {
+	resetSensitive(t)
+	RegisterSensitiveParam(`(?i)password\s*=\s*\?`)
+
+	entry := NewQueryLogEntry("/* who? */ SELECT /*+ NO_ICP(t) */ * FROM t WHERE note = 'why?' -- and?\n AND password = ?", []interface{}{"secret"})
+
+	if want := "SELECT /*+ NO_ICP(t) */ * FROM t WHERE note = 'why?'  \n AND password = ?"; entry.Query != want {
+		t.Errorf("Query = %q, want %q", entry.Query, want)
+	}
+	if want := []interface{}{redacted{}}; !reflect.DeepEqual(entry.Args, want) {
+		t.Errorf("Args = %v, want %v", entry.Args, want)
+	}
+}
@@ -0,0 +1,24 @@
+package db
+
+import "sync/atomic"
+
+var maxLoggedQueryLength atomic.Int64
+
+// Truncates queries longer than n characters in log output, appending "...[truncated]".
+// The query sent to MySQL is never truncated. 0 (the default) disables truncation.
+func SetMaxLoggedQueryLength(n int) {
+	maxLoggedQueryLength.Store(int64(n))
+}
+
+func truncateForLog(query string) string {
+	n := int(maxLoggedQueryLength.Load())
+	if n <= 0 || len(query) <= n {
+		return query
+	}
+
+	runes := []rune(query)
+	if len(runes) <= n {
+		return query
+	}
+	return string(runes[:n]) + "...[truncated]"
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"database/sql"
+	"expvar"
+	"sync"
+	"time"
+)
+
+var (
+	expvarMu       sync.Mutex
+	expvarPrefixes = map[string]bool{}
+)
+
+// Publishes the read and write pool statistics through expvar as `<prefix>.read` and `<prefix>.write`,
+// so they show up on the standard /debug/vars endpoint.
+//
+// The values are refreshed every 30 seconds unless another interval is given, until the returned function
+// is called, which also allows the prefix to be registered again. The refresh outlives CloseDB and
+// DrainAndClose, as the pools are opened again by the next query.
+// Registering the same prefix twice has no effect and returns a no-op.
+func RegisterExpvarMetrics(prefix string, interval ...time.Duration) (stop func()) {
+	if len(interval) == 0 || interval[0] <= 0 {
+		interval = []time.Duration{30 * time.Second}
+	}
+
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+
+	if expvarPrefixes[prefix] {
+		return func() {}
+	}
+	expvarPrefixes[prefix] = true
+
+	read := expvarMap(prefix + ".read")
+	write := expvarMap(prefix + ".write")
+
+	update := func() {
+		publishStats(read, poolStats(true))
+		publishStats(write, poolStats(false))
+	}
+	update()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval[0])
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				update()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+
+			expvarMu.Lock()
+			defer expvarMu.Unlock()
+			delete(expvarPrefixes, prefix)
+		})
+	}
+}
+
+func expvarMap(name string) *expvar.Map {
+	if m, ok := expvar.Get(name).(*expvar.Map); ok {
+		return m
+	}
+	return expvar.NewMap(name)
+}
+
+func publishStats(m *expvar.Map, stats sql.DBStats) {
+	set := func(key string, value int64) {
+		v := new(expvar.Int)
+		v.Set(value)
+		m.Set(key, v)
+	}
+
+	set("MaxOpenConnections", int64(stats.MaxOpenConnections))
+	set("OpenConnections", int64(stats.OpenConnections))
+	set("InUse", int64(stats.InUse))
+	set("Idle", int64(stats.Idle))
+	set("WaitCount", stats.WaitCount)
+	set("WaitDurationMs", stats.WaitDuration.Milliseconds())
+	set("MaxIdleClosed", stats.MaxIdleClosed)
+	set("MaxIdleTimeClosed", stats.MaxIdleTimeClosed)
+	set("MaxLifetimeClosed", stats.MaxLifetimeClosed)
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"expvar"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRegisterExpvarMetricsStops(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	stop := RegisterExpvarMetrics("test_pool", time.Millisecond)
+	if expvar.Get("test_pool.read") == nil || expvar.Get("test_pool.write") == nil {
+		t.Fatal("pool stats not published")
+	}
+	stop()
+	stop()
+
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > before; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines after stop, want %d", runtime.NumGoroutine(), before)
+		}
+	}
+
+	expvarMu.Lock()
+	registered := expvarPrefixes["test_pool"]
+	expvarMu.Unlock()
+	if registered {
+		t.Error("prefix still registered after stop")
+	}
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+)
+
+// The signature of the underlying QueryContext call made by every query function.
+type QueryFunc func(ctx context.Context, query string, args []interface{}) (*sql.Rows, error)
+
+// Wraps a QueryFunc, e.g. to rewrite queries, enforce quotas or record traces.
+type DBMiddleware func(next QueryFunc) QueryFunc
+
+var (
+	middlewareMu sync.RWMutex
+	middlewares  []DBMiddleware
+)
+
+// Installs middlewares around the query call of every query function, replacing the ones installed before.
+// The first middleware is the outermost one. Call WrapDB() without arguments to remove them all.
+func WrapDB(mws ...DBMiddleware) {
+	middlewareMu.Lock()
+	defer middlewareMu.Unlock()
+
+	middlewares = append([]DBMiddleware(nil), mws...)
+}
+
+// Logs every query with its duration and error, regardless of SetLogging.
+func LoggingMiddleware() DBMiddleware {
+	return func(next QueryFunc) QueryFunc {
+		return func(ctx context.Context, query string, args []interface{}) (*sql.Rows, error) {
+			st := time.Now()
+			rows, err := next(ctx, query, args)
+			if err != nil {
+				log.Printf("[%.2fms] %s: %v\n", float64(time.Since(st).Microseconds())/1000, queryToString(query, args), err)
+			} else {
+				log.Printf("[%.2fms] %s\n", float64(time.Since(st).Microseconds())/1000, queryToString(query, args))
+			}
+			return rows, err
+		}
+	}
+}
+
+// Starts a span for every query. start returns the context to run the query with and
+// a function ending the span with the query error.
+func TracingMiddleware(start func(ctx context.Context, query string) (context.Context, func(err error))) DBMiddleware {
+	return func(next QueryFunc) QueryFunc {
+		return func(ctx context.Context, query string, args []interface{}) (*sql.Rows, error) {
+			ctx, end := start(ctx, query)
+			rows, err := next(ctx, query, args)
+			end(err)
+			return rows, err
+		}
+	}
+}
+
+// Runs the query on db through the installed middlewares.
+func queryRows(ctx context.Context, db Queryer, query string, args []interface{}) (*sql.Rows, error) {
+	query, args, err := expandSliceArgs(query, args)
+	if err != nil {
+		return nil, err
+	}
+	query = applyQueryOptions(ctx, applyQueryFilter(ctx, query))
+
+	next := func(ctx context.Context, query string, args []interface{}) (*sql.Rows, error) {
+		logCostlyPlan(ctx, db, query, args)
+		logMatchingPlan(ctx, db, query, args)
+
+		if pool, ok := db.(*sql.DB); ok {
+			return cachedQuery(ctx, pool, query, args)
+		}
+		return db.QueryContext(ctx, query, args...)
+	}
+
+	rows, err := withMiddlewares(next)(ctx, query, args)
+	return rows, ClassifyError(err)
+}
+
+// Wraps next in the installed middlewares, the first one outermost.
+func withMiddlewares(next QueryFunc) QueryFunc {
+	middlewareMu.RLock()
+	defer middlewareMu.RUnlock()
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		next = middlewares[i](next)
+	}
+	return next
+}
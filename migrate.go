@@ -0,0 +1,229 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const migrationsTable = "_migrations"
+
+// A migration recorded as applied in the _migrations table.
+type MigrationRecord struct {
+	Version   int64     `db:"version"`
+	Name      string    `db:"name"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+func ensureMigrationsTable(ctx context.Context) error {
+	ctx = internalQuery(ctx)
+	db, err := dbFromContext(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	_, err = execFrom(ctx, db, "CREATE TABLE IF NOT EXISTS "+quoteIdent(migrationsTable)+` (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL DEFAULT '',
+		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`, nil)
+	return err
+}
+
+// Returns the highest applied migration version, 0 when none has been applied.
+// The _migrations table is created if it does not exist yet.
+func SchemaVersion(ctx context.Context) (int64, error) {
+	ctx = internalQuery(ctx)
+	if err := ensureMigrationsTable(ctx); err != nil {
+		return 0, err
+	}
+
+	db, err := dbFromContext(ctx, false)
+	if err != nil {
+		return 0, err
+	}
+
+	row, _, err := oneFrom[MigrationRecord](ctx, db,
+		"SELECT COALESCE(MAX(version), 0) AS version FROM "+quoteIdent(migrationsTable), nil)
+	return row.Version, err
+}
+
+// Forces the schema version, e.g. to recover from a migration that was applied or reverted by hand.
+// Versions above version are forgotten and version itself is recorded as applied if it is not already.
+// Setting version 0 forgets every migration.
+func SetSchemaVersion(ctx context.Context, version int64) error {
+	ctx = internalQuery(ctx)
+	if err := ensureMigrationsTable(ctx); err != nil {
+		return err
+	}
+
+	batch := []ExecItem{
+		{Query: "DELETE FROM " + quoteIdent(migrationsTable) + " WHERE version > ?", Args: []interface{}{version}},
+	}
+	if version > 0 {
+		batch = append(batch, ExecItem{
+			Query: "INSERT IGNORE INTO " + quoteIdent(migrationsTable) + " (version) VALUES (?)",
+			Args:  []interface{}{version},
+		})
+	}
+
+	_, err := ExecBatch(ctx, batch)
+	return err
+}
+
+// Returns every applied migration, oldest version first.
+func MigrationHistory(ctx context.Context) ([]MigrationRecord, error) {
+	ctx = internalQuery(ctx)
+	if err := ensureMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+
+	db, err := dbFromContext(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return allFrom[MigrationRecord](ctx, db,
+		"SELECT version, name, applied_at FROM "+quoteIdent(migrationsTable)+" ORDER BY version", nil)
+}
+
+// A schema change registered with RegisterMigration. Up and Down are run in order, each in a transaction
+// with the _migrations bookkeeping. MySQL commits DDL implicitly, so a failing DDL migration may be partially applied.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      []string
+	Down    []string
+}
+
+var (
+	migrationsMu sync.Mutex
+	migrations   = map[int64]Migration{}
+)
+
+// Registers a migration applied by RunMigrations and MigrateToVersion. Registering a version twice panics.
+func RegisterMigration(m Migration) {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+
+	if _, ok := migrations[m.Version]; ok {
+		panic(fmt.Sprintf("db: migration %d registered twice", m.Version))
+	}
+	migrations[m.Version] = m
+}
+
+// Returns the registered migrations, lowest version first.
+func registeredMigrations() []Migration {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+
+	res := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		res = append(res, m)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+	return res
+}
+
+// Applies every registered migration that has not been applied yet, lowest version first.
+func RunMigrations(ctx context.Context) error {
+	return MigrateToVersion(ctx, math.MaxInt64)
+}
+
+// Runs the Down statements of the last steps applied migrations, most recent first,
+// and removes them from the _migrations table. steps = -1 rolls back every migration.
+func MigrateDown(ctx context.Context, steps int) error {
+	history, err := MigrationHistory(ctx)
+	if err != nil {
+		return err
+	}
+
+	if steps < 0 || steps > len(history) {
+		steps = len(history)
+	}
+	for i := len(history) - 1; i >= len(history)-steps; i-- {
+		if err := revertMigration(ctx, history[i].Version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Applies or rolls back migrations until the applied ones are exactly the registered migrations up to targetVersion.
+func MigrateToVersion(ctx context.Context, targetVersion int64) error {
+	history, err := MigrationHistory(ctx)
+	if err != nil {
+		return err
+	}
+
+	applied := make(map[int64]bool, len(history))
+	for _, rec := range history {
+		applied[rec.Version] = true
+	}
+
+	for i := len(history) - 1; i >= 0 && history[i].Version > targetVersion; i-- {
+		if err := revertMigration(ctx, history[i].Version); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range registeredMigrations() {
+		if m.Version > targetVersion {
+			break
+		}
+		if applied[m.Version] {
+			continue
+		}
+		if err := runMigration(ctx, m.Version, m.Name, m.Up, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func revertMigration(ctx context.Context, version int64) error {
+	migrationsMu.Lock()
+	m, ok := migrations[version]
+	migrationsMu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("db: migration %d is applied but not registered", version)
+	}
+	if len(m.Down) == 0 {
+		return fmt.Errorf("db: migration %d has no Down statements", version)
+	}
+	return runMigration(ctx, m.Version, m.Name, m.Down, false)
+}
+
+// Runs the statements of a migration and records (up) or forgets (down) it, in one transaction.
+func runMigration(ctx context.Context, version int64, name string, statements []string, up bool) error {
+	ctx = internalQuery(ctx)
+	batch := make([]ExecItem, 0, len(statements)+1)
+	for _, stmt := range statements {
+		batch = append(batch, ExecItem{Query: stmt})
+	}
+
+	if up {
+		batch = append(batch, ExecItem{
+			Query: "INSERT INTO " + quoteIdent(migrationsTable) + " (version, name) VALUES (?, ?)",
+			Args:  []interface{}{version, name},
+		})
+	} else {
+		batch = append(batch, ExecItem{
+			Query: "DELETE FROM " + quoteIdent(migrationsTable) + " WHERE version = ?",
+			Args:  []interface{}{version},
+		})
+	}
+
+	if _, err := ExecBatch(ctx, batch); err != nil {
+		direction := "up"
+		if !up {
+			direction = "down"
+		}
+		return fmt.Errorf("db: migration %d %s: %w", version, direction, err)
+	}
+	return nil
+}
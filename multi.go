@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// A statement and its arguments.
+type Statement struct {
+	SQL  string
+	Args []interface{}
+}
+
+// The outcome of one statement run by MultiExecReport.
+type StatementResult struct {
+	Result   sql.Result
+	Err      error
+	Duration time.Duration
+}
+
+// Executes every statement independently on the write pool, without a wrapping transaction,
+// and reports the result of each one. A failing statement does not stop the ones after it.
+func MultiExecReport(ctx context.Context, statements []Statement) []StatementResult {
+	results := make([]StatementResult, len(statements))
+
+	db, err := dbFromContext(ctx, false)
+	if err != nil {
+		for i := range results {
+			results[i].Err = err
+		}
+		return results
+	}
+
+	for i, stmt := range statements {
+		st := time.Now()
+		res, err := execFrom(ctx, db, stmt.SQL, stmt.Args)
+		if err == nil {
+			publishQueryChange(stmt.SQL, stmt.Args)
+		}
+		results[i] = StatementResult{Result: res, Err: err, Duration: time.Since(st)}
+	}
+	return results
+}
+
+// A write statement run by ExecBatch.
+type ExecItem struct {
+	Query string
+	Args  []interface{}
+}
+
+// Executes every item in a single transaction on the write pool, or on the connection pinned to ctx,
+// and returns their results in order. The transaction is rolled back on the first error.
+func ExecBatch(ctx context.Context, batch []ExecItem) ([]sql.Result, error) {
+	var (
+		tx  *sql.Tx
+		err error
+	)
+	if conn, ok := pinnedConn(ctx); ok {
+		tx, err = conn.BeginTx(ctx, nil)
+	} else {
+		var pool *sql.DB
+		if pool, err = getPool(false); err == nil {
+			tx, err = pool.BeginTx(ctx, nil)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]sql.Result, len(batch))
+	for i, item := range batch {
+		res, err := execFrom(ctx, tx, item.Query, item.Args)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("db: batch item %d: %w", i, err)
+		}
+		results[i] = res
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, ClassifyError(err)
+	}
+
+	for _, item := range batch {
+		publishQueryChange(item.Query, item.Args)
+	}
+	return results, nil
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Same as Exec but panics on error, for init-time SQL such as test setup and schema creation.
+func MustExec(query string, args []interface{}) sql.Result {
+	res, err := Exec(query, args)
+	if err != nil {
+		panic(fmt.Sprintf("db: MustExec %s: %v", queryToString(query, args), err))
+	}
+	return res
+}
+
+// Returns the first row by value, panicking on error or when no row matches.
+func MustOne[T any](query string, args []interface{}) T {
+	res, err := OneOrError[T](context.Background(), query, args)
+	if err != nil {
+		panic(fmt.Sprintf("db: MustOne %s: %v", queryToString(query, args), err))
+	}
+	return res
+}
+
+// Returns every row, panicking on error.
+func MustAll[T any](query string, args []interface{}) []T {
+	res, err := allContext[T](context.Background(), query, args)
+	if err != nil {
+		panic(fmt.Sprintf("db: MustAll %s: %v", queryToString(query, args), err))
+	}
+	return res
+}
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+)
+
+// A database handle with its own read and write pools, for databases other than the one
+// configured through the environment.
+type NamedDB struct {
+	Name string
+
+	read   *lazyPool
+	write  *lazyPool
+	closed atomic.Bool
+
+	// Set on the handle behind the package-level functions, whose queries honour WithConnectionAffinity,
+	// UseWriteForNext and the replica lag fallback.
+	shared bool
+}
+
+// A database handle owning its connection pools, see New. Go methods cannot have type parameters,
+// so rows are scanned with OneOn and AllOn. The package-level functions run on a default handle
+// configured through the environment.
+type DB = NamedDB
+
+// Opens a handle on the database of cfg, independent of the package-level pools, e.g. to use several
+// databases in one process or give each test its own database. Reads and writes share one pool.
+// The caller MUST Close it.
+func New(cfg Config) (*DB, error) {
+	return OpenNamedDB(cfg.DBName, cfg)
+}
+
+// Opens the pools of a NamedDB. Reads go to the optional read config, or to the write config when omitted.
+func OpenNamedDB(name string, write Config, read ...Config) (*NamedDB, error) {
+	wdb, err := write.open()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &NamedDB{Name: name, write: &lazyPool{db: wdb}}
+	d.read = d.write
+	if len(read) > 0 {
+		rdb, err := read[0].open()
+		if err != nil {
+			wdb.Close()
+			return nil, err
+		}
+		d.read = &lazyPool{db: rdb}
+	}
+	return d, nil
+}
+
+// Returns the read (default) or write pool of the handle.
+func (d *NamedDB) DB(readOnly ...bool) *sql.DB {
+	if len(readOnly) == 0 || readOnly[0] {
+		return d.read.current()
+	}
+	return d.write.current()
+}
+
+// Returns what a query on d made with ctx runs on.
+func (d *NamedDB) queryer(ctx context.Context, readOnly bool) (Queryer, error) {
+	if d.closed.Load() {
+		return nil, ErrDBClosed
+	}
+
+	if d.shared {
+		if conn, ok := pinnedConn(ctx); ok {
+			return conn, nil
+		}
+		if readOnly && (takeWriteRead(ctx) || replicaTooFarBehind()) {
+			readOnly = false
+		}
+	}
+
+	if readOnly {
+		return d.read.get()
+	}
+	return d.write.get()
+}
+
+// Executes the statement on the write pool of d.
+func (d *NamedDB) Exec(query string, args []interface{}) (sql.Result, error) {
+	return d.ExecCtx(context.Background(), query, args)
+}
+
+// Same as Exec but honours the deadline and cancellation of ctx.
+func (d *NamedDB) ExecCtx(ctx context.Context, query string, args []interface{}) (sql.Result, error) {
+	db, err := d.queryer(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	return execFrom(ctx, db, query, args)
+}
+
+// Closes the read and write pools of the handle and their cached statements.
+// Queries made through d afterwards return ErrDBClosed, as does closing it again.
+func (d *NamedDB) Close() error {
+	if d.closed.Swap(true) {
+		return ErrDBClosed
+	}
+
+	write := d.write.take()
+	stmtCache.Forget(write)
+	if d.read == d.write {
+		return write.Close()
+	}
+
+	read := d.read.take()
+	stmtCache.Forget(read)
+	return errors.Join(read.Close(), write.Close())
+}
+
+// Same as One but runs on the read pool of d.
+func OneOn[T any](d *NamedDB, query string, args []interface{}) (*T, error) {
+	return OneOnCtx[T](context.Background(), d, query, args)
+}
+
+// Same as OneOn but honours the deadline and cancellation of ctx.
+func OneOnCtx[T any](ctx context.Context, d *NamedDB, query string, args []interface{}) (*T, error) {
+	db, err := d.queryer(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	res, found, err := oneFrom[T](ctx, db, query, args)
+	if !found {
+		return nil, err
+	}
+	return &res, err
+}
+
+// Same as All but runs on the read pool of d.
+func AllOn[T any](d *NamedDB, query string, args []interface{}) ([]T, error) {
+	return AllOnCtx[T](context.Background(), d, query, args)
+}
+
+// Same as AllOn but honours the deadline and cancellation of ctx.
+func AllOnCtx[T any](ctx context.Context, d *NamedDB, query string, args []interface{}) ([]T, error) {
+	db, err := d.queryer(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	return allFrom[T](ctx, db, query, args)
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestNamedDBRunsOnItsOwnPool(t *testing.T) {
+	pool := &lazyPool{db: openFakeDB([]string{"id"}, []driver.Value{int64(7)})}
+	d := &NamedDB{Name: "test", read: pool, write: pool}
+
+	row, err := OneOnCtx[struct{ ID int64 }](context.Background(), d, "SELECT id FROM t", nil)
+	if err != nil || row == nil || row.ID != 7 {
+		t.Fatalf("OneOnCtx = %v, %v, want ID 7", row, err)
+	}
+	if _, err := d.ExecCtx(context.Background(), "DELETE FROM t", nil); err != nil {
+		t.Fatalf("ExecCtx error = %v", err)
+	}
+
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close error = %v", err)
+	}
+	if _, err := d.Exec("DELETE FROM t", nil); !errors.Is(err, ErrDBClosed) {
+		t.Fatalf("Exec after Close error = %v, want ErrDBClosed", err)
+	}
+	if _, err := AllOn[struct{ ID int64 }](d, "SELECT id FROM t", nil); !errors.Is(err, ErrDBClosed) {
+		t.Fatalf("AllOn after Close error = %v, want ErrDBClosed", err)
+	}
+	if err := d.Close(); !errors.Is(err, ErrDBClosed) {
+		t.Fatalf("second Close error = %v, want ErrDBClosed", err)
+	}
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Rewrites the :name parameters of query into positional ? placeholders and returns the matching args,
+// e.g. "SELECT * FROM users WHERE id = :id" with map[string]interface{}{"id": 1}.
+//
+// params is a map with string keys, or a struct (or pointer to one) whose fields are looked up by
+// column name, as in ScanStruct, or by field name. Nil pointer fields bind NULL. A parameter may appear
+// several times. Colons inside quoted strings, identifiers and comments, and in := or ::, are left alone.
+func BindNamed(query string, params interface{}) (string, []interface{}, error) {
+	lookup, err := namedLookup(params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var (
+		b    strings.Builder
+		args []interface{}
+	)
+	for i := 0; i < len(query); i++ {
+		if end, ok, err := literalEnd(query, i); err != nil {
+			return "", nil, err
+		} else if ok {
+			b.WriteString(query[i : end+1])
+			i = end
+			continue
+		}
+
+		c := query[i]
+		switch {
+		case c == ':' && i+1 < len(query) && query[i+1] == ':':
+			b.WriteString("::")
+			i++
+			continue
+		case c == ':' && i+1 < len(query) && isNameStart(query[i+1]):
+			end := i + 1
+			for end < len(query) && isNameChar(query[end]) {
+				end++
+			}
+			name := query[i+1 : end]
+
+			value, ok := lookup(name)
+			if !ok {
+				return "", nil, fmt.Errorf("db: missing named parameter %q", name)
+			}
+			b.WriteByte('?')
+			args = append(args, value)
+			i = end - 1
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), args, nil
+}
+
+// Same as AllCtx with the named parameters of BindNamed.
+func AllNamed[T any](ctx context.Context, query string, params interface{}) ([]T, error) {
+	query, args, err := BindNamed(query, params)
+	if err != nil {
+		return nil, err
+	}
+	return allContext[T](ctx, query, args)
+}
+
+// Same as OneCtx with the named parameters of BindNamed.
+func OneNamed[T any](ctx context.Context, query string, params interface{}) (*T, error) {
+	query, args, err := BindNamed(query, params)
+	if err != nil {
+		return nil, err
+	}
+	return OneCtx[T](ctx, query, args)
+}
+
+// Same as ExecCtx with the named parameters of BindNamed.
+func ExecNamed(ctx context.Context, query string, params interface{}) (sql.Result, error) {
+	query, args, err := BindNamed(query, params)
+	if err != nil {
+		return nil, err
+	}
+	return ExecCtx(ctx, query, args)
+}
+
+// Returns a function looking up a named parameter in params.
+func namedLookup(params interface{}) (func(name string) (interface{}, bool), error) {
+	rv := reflect.ValueOf(params)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		return func(name string) (interface{}, bool) {
+			value := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+			if !value.IsValid() {
+				return nil, false
+			}
+			return value.Interface(), true
+		}, nil
+	case rv.Kind() == reflect.Struct:
+		values := map[string]interface{}{}
+		eachColumn(rv.Type(), nil, func(i int, field reflect.StructField, col string) {
+			value := rv.Field(i)
+			var v interface{}
+			if value.Kind() != reflect.Ptr || !value.IsNil() {
+				v = reflect.Indirect(value).Interface()
+			}
+			values[col] = v
+			values[field.Name] = v
+		})
+		return func(name string) (interface{}, bool) {
+			v, ok := values[name]
+			return v, ok
+		}, nil
+	}
+	return nil, fmt.Errorf("db: named parameters must be a map with string keys or a struct, got %T", params)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
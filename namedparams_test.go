@@ -0,0 +1,82 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBindNamed(t *testing.T) {
+	type filter struct {
+		UserID int64
+		Status *string `db:"state"`
+	}
+
+	params := map[string]interface{}{"id": 1, "name": "bob"}
+	tests := []struct {
+		name     string
+		query    string
+		params   interface{}
+		want     string
+		wantArgs []interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "map",
+			query:    "SELECT * FROM users WHERE id = :id AND name = :name",
+			params:   params,
+			want:     "SELECT * FROM users WHERE id = ? AND name = ?",
+			wantArgs: []interface{}{1, "bob"},
+		},
+		{
+			name:     "repeated name",
+			query:    "SELECT * FROM users WHERE id = :id OR parent_id = :id",
+			params:   params,
+			want:     "SELECT * FROM users WHERE id = ? OR parent_id = ?",
+			wantArgs: []interface{}{1, 1},
+		},
+		{
+			name:     "cast and assignment",
+			query:    "SELECT @n := :id, '1'::int",
+			params:   params,
+			want:     "SELECT @n := ?, '1'::int",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "literals and comments",
+			query:    "SELECT ':id', \":name\", `:id` /* :id */ FROM t -- :name\nWHERE id = :id # :name",
+			params:   params,
+			want:     "SELECT ':id', \":name\", `:id` /* :id */ FROM t -- :name\nWHERE id = ? # :name",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "struct pointer",
+			query:    "SELECT * FROM t WHERE user_id = :user_id AND uid = :UserID AND state = :state",
+			params:   &filter{UserID: 7},
+			want:     "SELECT * FROM t WHERE user_id = ? AND uid = ? AND state = ?",
+			wantArgs: []interface{}{int64(7), int64(7), nil},
+		},
+		{
+			name:    "missing key",
+			query:   "SELECT * FROM users WHERE id = :id AND email = :email",
+			params:  params,
+			wantErr: true,
+		},
+		{
+			name:    "unterminated string",
+			query:   "SELECT * FROM users WHERE name = ':name",
+			params:  params,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, args, err := BindNamed(tt.query, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("BindNamed = %q, %v, want %q, %v", got, args, tt.want, tt.wantArgs)
+			}
+		})
+	}
+}
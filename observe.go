@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+type rowObserver struct {
+	name string
+	fn   interface{} // func(T)
+}
+
+var (
+	observerMu sync.RWMutex
+	observers  = map[reflect.Type][]rowObserver{}
+)
+
+// Registers fn to be called with every row of type T returned by Observe, e.g. to warm a cache
+// with the rows another caller loads. Registering under a name already used for T replaces that observer.
+func RegisterRowObserver[T any](name string, fn func(T)) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	observerMu.Lock()
+	defer observerMu.Unlock()
+
+	list := append([]rowObserver(nil), observers[t]...)
+	for i, o := range list {
+		if o.name == name {
+			list[i].fn = fn
+			observers[t] = list
+			return
+		}
+	}
+	observers[t] = append(list, rowObserver{name: name, fn: fn})
+}
+
+// Same as Query, then calls the observers registered for T with each row, in row and registration order.
+// Observers run synchronously before Observe returns.
+func Observe[T any](query string, args []interface{}) ([]T, error) {
+	rows, err := allContext[T](context.Background(), query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	observerMu.RLock()
+	list := observers[reflect.TypeOf((*T)(nil)).Elem()]
+	observerMu.RUnlock()
+
+	for _, row := range rows {
+		for _, o := range list {
+			o.fn.(func(T))(row)
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// A page of rows sent by AllPaginated. Page starts at 1.
+type PageResult[T any] struct {
+	Items []T
+	Page  int
+	Err   error
+}
+
+// Runs the query page by page in the background, appending `LIMIT ? OFFSET ?` to it, and sends
+// each page on the returned channel. The channel is closed after the last page, after a page
+// carrying an error, or when ctx is cancelled.
+//
+// The query should have a deterministic ORDER BY, otherwise rows may be skipped or repeated between pages.
+func AllPaginated[T any](ctx context.Context, query string, args []interface{}, pageSize int) <-chan PageResult[T] {
+	ch := make(chan PageResult[T])
+
+	go func() {
+		defer close(ch)
+
+		if pageSize <= 0 {
+			send(ctx, ch, PageResult[T]{Page: 1, Err: errors.New("db: page size must be positive")})
+			return
+		}
+
+		pageQuery := strings.TrimRight(query, "; \t\r\n") + " LIMIT ? OFFSET ?"
+		for page := 1; ; page++ {
+			pageArgs := append(args[:len(args):len(args)], pageSize, (page-1)*pageSize)
+			items, err := allContext[T](ctx, pageQuery, pageArgs)
+
+			if len(items) == 0 && err == nil && page > 1 {
+				return
+			}
+
+			if !send(ctx, ch, PageResult[T]{Items: items, Page: page, Err: err}) {
+				return
+			}
+
+			if err != nil || len(items) < pageSize {
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// Sends v on ch unless ctx is done first.
+func send[T any](ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+)
+
+// A connection pool, opened on first use. Each pool has its own lock, so concurrent first queries open it
+// only once and a slow or unreachable replica does not hold up the write pool.
+type lazyPool struct {
+	mu   sync.Mutex
+	db   *sql.DB
+	open func() (*sql.DB, error)
+}
+
+var (
+	readPool  = lazyPool{open: func() (*sql.DB, error) { return openSharedPool(true) }}
+	writePool = lazyPool{open: func() (*sql.DB, error) { return openSharedPool(false) }}
+
+	// Opens a shared pool, replaced in tests
+	openSharedPool = openEnvPool
+
+	// The handle behind the package-level functions
+	defaultDB = &DB{read: &readPool, write: &writePool, shared: true}
+)
+
+// Opens the read or write pool configured by the environment, see envConfig.
+func openEnvPool(readOnly bool) (*sql.DB, error) {
+	cfg, err := envConfig(readOnly)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.open()
+}
+
+// Returns the shared read (default) or write connection pool, opening it on first use.
+// Reads use the write pool while the replica lags too far behind, see SetReplicaLagFallback.
+//
+// Unlike GetDB the pool is reused between queries and MUST NOT be closed by the caller.
+// A pool that cannot be opened is not kept, the next query tries again.
+func getPool(readOnly ...bool) (*sql.DB, error) {
+	ro := len(readOnly) == 0 || readOnly[0]
+	if ro && replicaTooFarBehind() {
+		ro = false
+	}
+	return poolFor(ro)
+}
+
+func poolFor(readOnly bool) (*sql.DB, error) {
+	if readOnly {
+		return readPool.get()
+	}
+	return writePool.get()
+}
+
+// Returns the pool, opening it if needed. A pool that has been taken and cannot be reopened returns ErrDBClosed.
+func (p *lazyPool) get() (*sql.DB, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.db == nil {
+		if p.open == nil {
+			return nil, ErrDBClosed
+		}
+		db, err := p.open()
+		if err != nil {
+			return nil, err
+		}
+		p.db = db
+	}
+	return p.db, nil
+}
+
+// Returns the pool if it is open, without opening it.
+func (p *lazyPool) current() *sql.DB {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.db
+}
+
+// Detaches the pool, the next query opens a new one.
+func (p *lazyPool) take() *sql.DB {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	db := p.db
+	p.db = nil
+	return db
+}
+
+// Returns the statistics of the read or write pool, or zero stats if it has not been opened yet.
+func poolStats(readOnly bool) sql.DBStats {
+	pool := writePool.current()
+	if readOnly {
+		pool = readPool.current()
+	}
+
+	if pool == nil {
+		return sql.DBStats{}
+	}
+	return pool.Stats()
+}
+
+// Closes the shared read and write pools, e.g. on shutdown or before switching the environment
+// to another database. They are opened again by the next query.
+func CloseDB() error {
+	stmtCache.Clear()
+
+	var errs []error
+	for _, p := range []*lazyPool{&readPool, &writePool} {
+		if pool := p.take(); pool != nil {
+			errs = append(errs, pool.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var drainPollInterval = 100 * time.Millisecond
+
+// Sets how often DrainAndClose checks for in-flight queries (default 100ms).
+func SetDrainPollInterval(d time.Duration) {
+	if d > 0 {
+		drainPollInterval = d
+	}
+}
+
+// Detaches the shared pools, waits until none of their connections is in use and then closes them,
+// so in-flight queries can finish during a graceful shutdown. If ctx expires first the pools are
+// closed anyway and the context error is returned.
+//
+// database/sql cannot refuse new connections (SetMaxOpenConns(0) means unlimited), so callers
+// should stop issuing queries first: any query started afterwards opens new pools.
+func DrainAndClose(ctx context.Context) error {
+	stmtCache.Clear()
+
+	var pools []*sql.DB
+	for _, p := range []*lazyPool{&readPool, &writePool} {
+		if pool := p.take(); pool != nil {
+			pools = append(pools, pool)
+		}
+	}
+
+	closeAll := func() error {
+		var errs []error
+		for _, pool := range pools {
+			errs = append(errs, pool.Close())
+		}
+		return errors.Join(errs...)
+	}
+
+	// Close connections as soon as they are released instead of keeping them idle
+	for _, pool := range pools {
+		pool.SetMaxIdleConns(0)
+	}
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for {
+		inUse := 0
+		for _, pool := range pools {
+			inUse += pool.Stats().InUse
+		}
+		if inUse == 0 {
+			return closeAll()
+		}
+
+		select {
+		case <-ctx.Done():
+			closeAll()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
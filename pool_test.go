@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolForOpensEachPoolOnce(t *testing.T) {
+	var opened [2]atomic.Int32
+	defer func(open func(bool) (*sql.DB, error)) { openSharedPool = open }(openSharedPool)
+	openSharedPool = func(readOnly bool) (*sql.DB, error) {
+		i := 0
+		if readOnly {
+			i = 1
+		}
+		opened[i].Add(1)
+		// Widen the window in which concurrent first queries race for the pool
+		time.Sleep(10 * time.Millisecond)
+		return openFakeDB(nil), nil
+	}
+	defer CloseDB()
+
+	const n = 50
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+		pools [2][n]*sql.DB
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			pools[1][i], _ = poolFor(true)
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			pools[0][i], _ = poolFor(false)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, name := range []string{"write", "read"} {
+		if got := opened[i].Load(); got != 1 {
+			t.Errorf("%s pool opened %d times, want 1", name, got)
+		}
+		for _, pool := range pools[i] {
+			if pool != pools[i][0] {
+				t.Fatalf("%s pool differs between goroutines", name)
+			}
+		}
+	}
+	if pools[0][0] == pools[1][0] {
+		t.Error("read and write pools are the same")
+	}
+}
+
+func TestPoolForReturnsOpenError(t *testing.T) {
+	defer func(open func(bool) (*sql.DB, error)) { openSharedPool = open }(openSharedPool)
+	fail := errors.New("unreachable")
+	openSharedPool = func(bool) (*sql.DB, error) {
+		return nil, fail
+	}
+	defer CloseDB()
+
+	if _, err := OneE[struct{ ID int }]("SELECT 1", nil); !errors.Is(err, fail) {
+		t.Fatalf("OneE error = %v, want %v", err, fail)
+	}
+	if _, err := ExecCtx(context.Background(), "DO 1", nil); !errors.Is(err, fail) {
+		t.Fatalf("ExecCtx error = %v, want %v", err, fail)
+	}
+
+	// The failed open is not kept
+	openSharedPool = func(bool) (*sql.DB, error) {
+		return openFakeDB(nil), nil
+	}
+	if _, err := poolFor(true); err != nil {
+		t.Fatalf("poolFor error = %v after the database came back", err)
+	}
+}
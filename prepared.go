@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Executes a statement prepared with db.Prepare, with the same query logging and dry-run handling as Exec.
+// query is the SQL stmt was prepared from, *sql.Stmt does not expose it; it is only used for logging.
+func ExecPrepared(stmt *sql.Stmt, query string, args []interface{}) (sql.Result, error) {
+	if res, skipped := skipDryRun(query, args); skipped {
+		return res, nil
+	}
+
+	defer timer(query, args)()
+
+	res, err := stmt.Exec(args...)
+	return res, ClassifyError(err)
+}
+
+// Runs a statement prepared with db.Prepare through the WrapDB middlewares and scans every row like All.
+// query is the SQL stmt was prepared from, used for logging and passed to the middlewares;
+// a middleware rewriting it has no effect on the prepared statement.
+func QueryPrepared[T any](stmt *sql.Stmt, query string, args []interface{}) ([]T, error) {
+	defer timer(query, args)()
+
+	next := withMiddlewares(func(ctx context.Context, _ string, args []interface{}) (*sql.Rows, error) {
+		return stmt.QueryContext(ctx, args...)
+	})
+	rows, err := next(context.Background(), query, args)
+	if err != nil {
+		return nil, ClassifyError(err)
+	}
+	defer rows.Close()
+
+	return scanAll[T](rows)
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestQueryPreparedLogsAndRunsMiddlewares(t *testing.T) {
+	resetSensitive(t)
+	RegisterSensitiveParam(`(?i)token\s*=\s*\?`)
+
+	var buf bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&buf)
+	SetLogging(true)
+	defer SetLogging(false)
+
+	var seen []string
+	WrapDB(func(next QueryFunc) QueryFunc {
+		return func(ctx context.Context, query string, args []interface{}) (*sql.Rows, error) {
+			seen = append(seen, query)
+			return next(ctx, query, args)
+		}
+	})
+	defer WrapDB()
+
+	pool := openFakeDB([]string{"id"}, []driver.Value{int64(3)})
+	defer pool.Close()
+
+	const query = "SELECT id FROM sessions WHERE token = ?"
+	stmt, err := pool.Prepare(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+
+	rows, err := QueryPrepared[struct{ ID int64 }](stmt, query, []interface{}{"s3cret"})
+	if err != nil || len(rows) != 1 || rows[0].ID != 3 {
+		t.Fatalf("QueryPrepared = %v, %v, want ID 3", rows, err)
+	}
+	if len(seen) != 1 || seen[0] != query {
+		t.Errorf("middleware saw %q, want the prepared query", seen)
+	}
+
+	if _, err := ExecPrepared(stmt, query, []interface{}{"s3cret"}); err != nil {
+		t.Fatalf("ExecPrepared error = %v", err)
+	}
+
+	logged := buf.String()
+	if strings.Count(logged, "SELECT id FROM sessions WHERE token = [REDACTED]") != 2 || strings.Contains(logged, "s3cret") {
+		t.Errorf("log = %q, want both calls logged with the token redacted", logged)
+	}
+}
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	selectHintRegex = regexp.MustCompile(`(?is)^(\s*\(?\s*SELECT\b)(\s*/\*\+)?`)
+
+	requestIDKeyMu sync.RWMutex
+	requestIDKey   interface{}
+)
+
+// Changes how the queries run with the context returned by WithQueryOptions are sent to the server.
+type QueryOption func(*queryOptions)
+
+type queryOptions struct {
+	hintTimeout int
+	comment     string
+}
+
+type queryOptionsKey struct{}
+
+// Returns a context applying opts to every query and statement run with it (or contexts derived from it),
+// on top of the options already set on ctx.
+func WithQueryOptions(ctx context.Context, opts ...QueryOption) context.Context {
+	o := queryOptionsFrom(ctx)
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return context.WithValue(ctx, queryOptionsKey{}, o)
+}
+
+// Adds the MAX_EXECUTION_TIME(ms) optimizer hint to SELECT queries (MySQL 5.7.8+), so the server itself
+// aborts them after ms milliseconds. Unlike a context deadline, which only stops waiting on the client side,
+// the server stops executing the query. Both can be used together. Statements other than SELECT are not changed.
+func WithMySQLHintTimeout(ms int) QueryOption {
+	return func(o *queryOptions) {
+		o.hintTimeout = ms
+	}
+}
+
+// Prepends comment as /* comment */ to every query and statement, so it shows up in SHOW PROCESSLIST,
+// the slow query log and performance_schema, e.g. WithComment("app=billing,req=abc123").
+//
+// Per-request comments make every query text unique, which defeats the prepared statement cache.
+func WithComment(comment string) QueryOption {
+	return func(o *queryOptions) {
+		o.comment = comment
+	}
+}
+
+// Builds a structured query comment of key=value pairs for WithComment, e.g.
+//
+//	ctx = db.WithQueryOptions(ctx, db.WithComment(new(db.CommentBuilder).SetApp("billing").SetUser(user).String()))
+type CommentBuilder struct {
+	app       string
+	requestID string
+	user      string
+}
+
+func (b *CommentBuilder) SetApp(name string) *CommentBuilder {
+	b.app = name
+	return b
+}
+
+func (b *CommentBuilder) SetRequestID(id string) *CommentBuilder {
+	b.requestID = id
+	return b
+}
+
+func (b *CommentBuilder) SetUser(user string) *CommentBuilder {
+	b.user = user
+	return b
+}
+
+// Returns the comment, e.g. "app=billing,req=abc123,user=bob". Empty values are left out.
+func (b *CommentBuilder) String() string {
+	var pairs []string
+	for _, pair := range [][2]string{{"app", b.app}, {"req", b.requestID}, {"user", b.user}} {
+		if pair[1] != "" {
+			pairs = append(pairs, pair[0]+"="+pair[1])
+		}
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Registers the context key holding the request ID. When a query context carries a value under key,
+// it is added to the query comment as req=<value>, unless the comment set with WithComment already has a req.
+// Pass nil to stop.
+func SetRequestIDContextKey(key interface{}) {
+	requestIDKeyMu.Lock()
+	defer requestIDKeyMu.Unlock()
+
+	requestIDKey = key
+}
+
+func queryOptionsFrom(ctx context.Context) queryOptions {
+	o, _ := ctx.Value(queryOptionsKey{}).(queryOptions)
+	return o
+}
+
+// Rewrites query according to the query options of ctx.
+func applyQueryOptions(ctx context.Context, query string) string {
+	o := queryOptionsFrom(ctx)
+	if o.hintTimeout > 0 {
+		query = addSelectHint(query, fmt.Sprintf("MAX_EXECUTION_TIME(%d)", o.hintTimeout))
+	}
+
+	comment := o.comment
+	if id := requestIDFrom(ctx); id != "" && !strings.Contains(comment, "req=") {
+		if comment != "" {
+			comment += ","
+		}
+		comment += "req=" + id
+	}
+	if comment != "" {
+		// A */ in the comment would end it early and let the rest run as SQL
+		query = "/* " + strings.ReplaceAll(comment, "*/", "* /") + " */ " + query
+	}
+	return query
+}
+
+func requestIDFrom(ctx context.Context) string {
+	requestIDKeyMu.RLock()
+	key := requestIDKey
+	requestIDKeyMu.RUnlock()
+
+	if key == nil {
+		return ""
+	}
+	if id := ctx.Value(key); id != nil {
+		return fmt.Sprint(id)
+	}
+	return ""
+}
+
+// Adds hint to the optimizer hint comment right after the SELECT keyword, where MySQL expects it,
+// creating the comment when the query has none. Leading comments are skipped and queries not starting
+// with SELECT are returned as is.
+func addSelectHint(query string, hint string) string {
+	head := len(query) - len(trimLeadingComments(query))
+	loc := selectHintRegex.FindStringSubmatchIndex(query[head:])
+	if loc == nil {
+		return query
+	}
+	if loc[4] >= 0 {
+		return query[:head+loc[5]] + " " + hint + query[head+loc[5]:]
+	}
+	return query[:head+loc[3]] + " /*+ " + hint + " */" + query[head+loc[3]:]
+}
@@ -0,0 +1,25 @@
+package db
+
+import "testing"
+
+func TestAddSelectHint(t *testing.T) {
+	const hint = "MAX_EXECUTION_TIME(500)"
+	tests := []struct{ query, want string }{
+		{"SELECT * FROM t", "SELECT /*+ MAX_EXECUTION_TIME(500) */ * FROM t"},
+		{"  select id FROM t", "  select /*+ MAX_EXECUTION_TIME(500) */ id FROM t"},
+		{"SELECT DISTINCT name FROM t", "SELECT /*+ MAX_EXECUTION_TIME(500) */ DISTINCT name FROM t"},
+		{"SELECT SQL_CALC_FOUND_ROWS * FROM t", "SELECT /*+ MAX_EXECUTION_TIME(500) */ SQL_CALC_FOUND_ROWS * FROM t"},
+		{"SELECT /*+ BKA(t) */ * FROM t", "SELECT /*+ MAX_EXECUTION_TIME(500) BKA(t) */ * FROM t"},
+		{"/* app=billing */ SELECT * FROM t", "/* app=billing */ SELECT /*+ MAX_EXECUTION_TIME(500) */ * FROM t"},
+		{"/* a */ /* b */\nSELECT DISTINCT id FROM t", "/* a */ /* b */\nSELECT /*+ MAX_EXECUTION_TIME(500) */ DISTINCT id FROM t"},
+		{"SELECT * FROM (SELECT id FROM t) AS s", "SELECT /*+ MAX_EXECUTION_TIME(500) */ * FROM (SELECT id FROM t) AS s"},
+		{"(SELECT id FROM a) UNION (SELECT id FROM b)", "(SELECT /*+ MAX_EXECUTION_TIME(500) */ id FROM a) UNION (SELECT id FROM b)"},
+		{"UPDATE t SET n = (SELECT 1)", "UPDATE t SET n = (SELECT 1)"},
+		{"SELECTED", "SELECTED"},
+	}
+	for _, tt := range tests {
+		if got := addSelectHint(tt.query, hint); got != tt.want {
+			t.Errorf("addSelectHint(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+)
+
+// Customises SelectRandom.
+type RandomOption func(*randomOptions)
+
+type randomOptions struct {
+	fast bool
+}
+
+// Makes SelectRandom draw n random primary keys and read the first row at or after each of them, instead
+// of sorting the whole table with ORDER BY RAND(). Each row costs one index lookup, which suits large tables,
+// but gaps in the keys bias the sample towards the rows that follow them. Keys landing on an already drawn row
+// are drawn again a few times, so fewer than n rows may be returned on small or sparse tables.
+// The primary key of T, found as in Find, must be numeric.
+func WithFastRandom(fast bool) RandomOption {
+	return func(o *randomOptions) {
+		o.fast = fast
+	}
+}
+
+// Returns up to n rows of tableName in random order, e.g. for A/B test assignment, sampling or fuzzing.
+// ORDER BY RAND() is used by default, which scans the whole table: use WithFastRandom on large tables.
+func SelectRandom[T any](ctx context.Context, tableName string, n int, opts ...RandomOption) ([]T, error) {
+	var o randomOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if !identRegex.MatchString(tableName) {
+		return nil, fmt.Errorf("db: invalid identifier %q", tableName)
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+
+	if !o.fast {
+		return allContext[T](ctx, fmt.Sprintf("SELECT * FROM %s ORDER BY RAND() LIMIT ?", quoteIdent(tableName)), []interface{}{n})
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	pk, ok := primaryKey(t)
+	if !ok {
+		return nil, fmt.Errorf("db: %s has no primary key field, tag one with `db:\"name,pk\"`", t)
+	}
+
+	var minID, maxID sql.NullInt64
+	if err := ColumnCtx(ctx, fmt.Sprintf("SELECT MIN(%s), MAX(%s) FROM %s", quoteIdent(pk), quoteIdent(pk), quoteIdent(tableName)), nil, &minID, &maxID); err != nil {
+		return nil, err
+	}
+	if !minID.Valid || !maxID.Valid {
+		return nil, nil
+	}
+
+	lookup := fmt.Sprintf("(SELECT * FROM %s WHERE %s >= ? ORDER BY %s LIMIT 1)", quoteIdent(tableName), quoteIdent(pk), quoteIdent(pk))
+
+	var (
+		res  []T
+		seen = map[interface{}]bool{}
+	)
+	for attempt := 0; attempt < randomAttempts && len(res) < n; attempt++ {
+		keys := make([]interface{}, n-len(res))
+		for i := range keys {
+			keys[i] = randomBetween(minID.Int64, maxID.Int64)
+		}
+
+		rows, err := allContext[T](ctx, strings.TrimSuffix(strings.Repeat(lookup+" UNION ALL ", len(keys)), " UNION ALL "), keys)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if key := fieldByColumn(reflect.ValueOf(row), pk).Interface(); !seen[key] {
+				seen[key] = true
+				res = append(res, row)
+			}
+		}
+	}
+	return res, nil
+}
+
+// How many times SelectRandom draws keys again for the rows it already has.
+const randomAttempts = 3
+
+// Returns the field of the struct value v mapped to column col.
+func fieldByColumn(v reflect.Value, col string) reflect.Value {
+	var field reflect.Value
+	eachColumn(v.Type(), nil, func(i int, _ reflect.StructField, c string) {
+		if c == col {
+			field = v.Field(i)
+		}
+	})
+	return field
+}
+
+// Returns a random integer in [lo, hi], which may span the whole int64 range.
+func randomBetween(lo, hi int64) int64 {
+	span := uint64(hi) - uint64(lo)
+	if span == math.MaxUint64 {
+		return int64(rand.Uint64())
+	}
+	// Wraps around like the unsigned span, landing back in [lo, hi]
+	return lo + int64(rand.Uint64()%(span+1))
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRandomBetween(t *testing.T) {
+	tests := []struct{ lo, hi int64 }{
+		{0, 0},
+		{5, 10},
+		{-10, -5},
+		{-3, 3},
+		{math.MaxInt64 - 2, math.MaxInt64},
+		{math.MinInt64, math.MinInt64 + 2},
+		{math.MinInt64, math.MaxInt64},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if got := randomBetween(tt.lo, tt.hi); got < tt.lo || got > tt.hi {
+				t.Fatalf("randomBetween(%d, %d) = %d", tt.lo, tt.hi, got)
+			}
+		}
+	}
+}
+
+func TestSelectRandomFastSamplesIndependentRows(t *testing.T) {
+	pool, fake := openFakeConnector(nil)
+	useSharedPool(t, pool)
+
+	// Emulates a table holding the ids 1 to 1000
+	fake.respond = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		if strings.Contains(query, "MIN(") {
+			return []string{"min", "max"}, [][]driver.Value{{int64(1), int64(1000)}}
+		}
+		var rows [][]driver.Value
+		for _, arg := range args {
+			rows = append(rows, []driver.Value{arg})
+		}
+		return []string{"id"}, rows
+	}
+
+	type item struct {
+		ID int64
+	}
+	rows, err := SelectRandom[item](context.Background(), "items", 10, WithFastRandom(true))
+	if err != nil {
+		t.Fatalf("SelectRandom error = %v", err)
+	}
+	if len(rows) != 10 {
+		t.Fatalf("got %d rows, want 10", len(rows))
+	}
+
+	ids := make([]int64, len(rows))
+	seen := map[int64]bool{}
+	for i, row := range rows {
+		if seen[row.ID] {
+			t.Fatalf("id %d returned twice", row.ID)
+		}
+		seen[row.ID] = true
+		ids[i] = row.ID
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if ids[len(ids)-1]-ids[0] == int64(len(ids)-1) {
+		t.Errorf("ids %v are consecutive, want an independent sample", ids)
+	}
+}
@@ -0,0 +1,183 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// redacted replaces a sensitive argument when a query is rendered for logging.
+type redacted struct{}
+
+func (redacted) String() string { return "[REDACTED]" }
+
+type sensitiveTable struct {
+	table   *regexp.Regexp   // finds the table in a query
+	compare []*regexp.Regexp // `col = ?` style comparisons
+	columns map[string]bool  // lowercase column names, for INSERT column lists
+}
+
+var (
+	sensitiveMu     sync.RWMutex
+	sensitiveParams []*regexp.Regexp
+	sensitiveTables []sensitiveTable
+)
+
+// RegisterSensitiveParam redacts the values of every placeholder covered by a match of
+// pattern in the query template. The pattern is applied to the SQL, never to the values.
+//
+//	db.RegisterSensitiveParam(`(?i)password\s*=\s*\?`)
+//
+// Redaction only affects logging, the query sent to MySQL is left untouched.
+func RegisterSensitiveParam(pattern string) {
+	re := regexp.MustCompile(pattern)
+
+	sensitiveMu.Lock()
+	defer sensitiveMu.Unlock()
+	sensitiveParams = append(sensitiveParams, re)
+}
+
+// RegisterSensitiveTable redacts the values bound to cols whenever a query references table,
+// both in comparisons (`col = ?`) and in INSERT/REPLACE column lists.
+func RegisterSensitiveTable(table string, cols ...string) {
+	st := sensitiveTable{
+		table:   regexp.MustCompile(`(?i)(^|[^\w$])` + "`?" + regexp.QuoteMeta(table) + "`?" + `($|[^\w$])`),
+		columns: map[string]bool{},
+	}
+	for _, col := range cols {
+		st.columns[strings.ToLower(col)] = true
+		st.compare = append(st.compare, regexp.MustCompile(`(?i)(^|[^\w$])`+"`?"+regexp.QuoteMeta(col)+"`?"+`\s*(=|<=>|!=|<>|\bLIKE\b|\bIN\b)\s*\(?\s*\?`))
+	}
+
+	sensitiveMu.Lock()
+	defer sensitiveMu.Unlock()
+	sensitiveTables = append(sensitiveTables, st)
+}
+
+// redactArgs returns a copy of args with every sensitive value replaced by [REDACTED].
+func redactArgs(query string, args []interface{}) []interface{} {
+	sensitiveMu.RLock()
+	defer sensitiveMu.RUnlock()
+
+	if len(args) == 0 || (len(sensitiveParams) == 0 && len(sensitiveTables) == 0) {
+		return args
+	}
+
+	offsets := placeholderOffsets(query)
+
+	hidden := map[int]bool{}
+	hideRange := func(start, end int) {
+		for i, offset := range offsets {
+			if offset >= start && offset < end {
+				hidden[i] = true
+			}
+		}
+	}
+
+	for _, re := range sensitiveParams {
+		for _, loc := range re.FindAllStringIndex(query, -1) {
+			hideRange(loc[0], loc[1])
+		}
+	}
+
+	for _, st := range sensitiveTables {
+		if !st.table.MatchString(query) {
+			continue
+		}
+
+		for _, re := range st.compare {
+			for _, loc := range re.FindAllStringIndex(query, -1) {
+				hideRange(loc[0], loc[1])
+			}
+		}
+
+		for _, offset := range insertedPlaceholders(query, st) {
+			hideRange(offset, offset+1)
+		}
+	}
+
+	if len(hidden) == 0 {
+		return args
+	}
+
+	res := make([]interface{}, len(args))
+	for i, arg := range args {
+		if hidden[i] {
+			res[i] = redacted{}
+		} else {
+			res[i] = arg
+		}
+	}
+	return res
+}
+
+// placeholderOffsets returns the offsets of the ? placeholders of query, skipping the ones inside
+// quoted strings, identifiers and comments.
+func placeholderOffsets(query string) []int {
+	var offsets []int
+	for i := 0; i < len(query); i++ {
+		if end, ok, err := literalEnd(query, i); err != nil {
+			// The rest of the query is an unterminated string or comment
+			break
+		} else if ok {
+			i = end
+			continue
+		}
+
+		if query[i] == '?' {
+			offsets = append(offsets, i)
+		}
+	}
+	return offsets
+}
+
+var insertColumnsRegex = regexp.MustCompile(`(?is)\bINTO\s+([\w$.` + "`" + `]+)\s*\(([^)]*)\)\s*VALUES\s*`)
+
+// insertedPlaceholders returns the offsets of the placeholders bound to sensitive columns
+// in an `INSERT INTO table (cols...) VALUES (...), (...)` statement.
+func insertedPlaceholders(query string, st sensitiveTable) []int {
+	m := insertColumnsRegex.FindStringSubmatchIndex(query)
+	if m == nil || !st.table.MatchString(query[m[2]:m[3]]) {
+		return nil
+	}
+
+	var sensitive []bool
+	for _, col := range strings.Split(query[m[4]:m[5]], ",") {
+		col = strings.ToLower(strings.Trim(strings.TrimSpace(col), "`"))
+		sensitive = append(sensitive, st.columns[col])
+	}
+
+	var (
+		offsets []int
+		depth   int
+		column  int
+	)
+	for i := m[1]; i < len(query); i++ {
+		if end, ok, err := literalEnd(query, i); err != nil {
+			break
+		} else if ok {
+			i = end
+			continue
+		}
+
+		switch c := query[i]; {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				column = 0
+			}
+		case c == ',' && depth == 1:
+			column++
+		case c == '?' && depth > 0:
+			if column < len(sensitive) && sensitive[column] {
+				offsets = append(offsets, i)
+			}
+		case depth == 0 && c != ',' && c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			// End of the VALUES list, e.g. ON DUPLICATE KEY UPDATE.
+			return offsets
+		}
+	}
+	return offsets
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Restores the registered sensitive params and tables once the test is done.
+func resetSensitive(t *testing.T) {
+	sensitiveMu.Lock()
+	params, tables := sensitiveParams, sensitiveTables
+	sensitiveMu.Unlock()
+
+	t.Cleanup(func() {
+		sensitiveMu.Lock()
+		defer sensitiveMu.Unlock()
+		sensitiveParams, sensitiveTables = params, tables
+	})
+}
+
+func TestRedactArgsSkipsLiteralsAndComments(t *testing.T) {
+	resetSensitive(t)
+	RegisterSensitiveParam(`(?i)password\s*=\s*\?`)
+	RegisterSensitiveTable("users", "token")
+
+	tests := []struct {
+		query string
+		args  []interface{}
+		want  []interface{}
+	}{
+		{
+			query: "SELECT * FROM accounts WHERE note = 'why?' AND password = ? AND id = ?",
+			args:  []interface{}{"secret", 1},
+			want:  []interface{}{redacted{}, 1},
+		},
+		{
+			query: "SELECT * FROM accounts /* who? */ WHERE id = ? -- really?\n AND password = ?",
+			args:  []interface{}{1, "secret"},
+			want:  []interface{}{1, redacted{}},
+		},
+		{
+			query: "INSERT INTO users (name, token) VALUES ('a?(', ?), (?, ?)",
+			args:  []interface{}{"t1", "b", "t2"},
+			want:  []interface{}{redacted{}, "b", redacted{}},
+		},
+	}
+	for _, tt := range tests {
+		if got := redactArgs(tt.query, tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("redactArgs(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
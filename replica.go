@@ -0,0 +1,149 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	// How long a measured replica lag is reused before the read pool is checked again.
+	replicaLagCheckInterval = time.Second
+	// How long a replica lag check may take before the lag is considered unknown.
+	replicaLagCheckTimeout = 5 * time.Second
+)
+
+var (
+	lagMu          sync.Mutex
+	maxReplicaLag  time.Duration
+	highLagFn      func(lag time.Duration)
+	lagFallback    bool
+	lastLag        time.Duration
+	lagMeasured    bool // false until the first check succeeds and after a failed one
+	lastLagCheck   time.Time
+	lagCheckActive bool
+)
+
+// Returns how far the read pool is behind its source, read from SHOW REPLICA STATUS
+// (MySQL 8.0.22+) or SHOW SLAVE STATUS. A server that is not a replica reports no lag.
+func GetReplicaLag(ctx context.Context) (time.Duration, error) {
+	db, err := poolFor(true)
+	if err != nil {
+		return 0, err
+	}
+	return replicaLag(ctx, db)
+}
+
+// Sets the replica lag above which reads are considered stale, checked at most once per second.
+// Zero (the default) disables the check.
+func SetMaxAllowedReplicaLag(d time.Duration) {
+	lagMu.Lock()
+	defer lagMu.Unlock()
+
+	maxReplicaLag = d
+}
+
+// Sets a function called with the measured lag whenever it exceeds the maximum set by SetMaxAllowedReplicaLag.
+func SetHighLagCallback(fn func(lag time.Duration)) {
+	lagMu.Lock()
+	defer lagMu.Unlock()
+
+	highLagFn = fn
+}
+
+// Sets whether reads are sent to the write pool while the replica lag exceeds the maximum,
+// or is unknown because it has not been measured yet or could not be. Disabled by default,
+// in which case only the callback is notified.
+func SetReplicaLagFallback(enabled bool) {
+	lagMu.Lock()
+	defer lagMu.Unlock()
+
+	lagFallback = enabled
+}
+
+func replicaLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	rows, err := db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		rows, err = db.QueryContext(ctx, "SHOW SLAVE STATUS")
+		if err != nil {
+			return 0, err
+		}
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	values := make([]interface{}, len(cols))
+	for i := range values {
+		values[i] = &values[i]
+	}
+	if err := rows.Scan(values...); err != nil {
+		return 0, err
+	}
+
+	for _, key := range []string{"Seconds_Behind_Source", "Seconds_Behind_Master"} {
+		i := IndexOf(key, cols)
+		if i < 0 {
+			continue
+		}
+		if values[i] == nil {
+			return 0, errors.New("db: replication is not running")
+		}
+
+		var seconds int64
+		if err := setFieldFromInterface(reflect.ValueOf(&seconds).Elem(), values[i]); err != nil {
+			return 0, err
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, errors.New("db: replica status has no Seconds_Behind_Source column")
+}
+
+// Reports whether reads should go to the write pool because the replica is too far behind.
+// The lag is measured in the background once the check interval expires, reads meanwhile
+// use the last measurement.
+func replicaTooFarBehind() bool {
+	lagMu.Lock()
+	defer lagMu.Unlock()
+
+	if maxReplicaLag <= 0 {
+		return false
+	}
+
+	if !lagCheckActive && time.Since(lastLagCheck) >= replicaLagCheckInterval {
+		lagCheckActive = true
+		go checkReplicaLag()
+	}
+	return lagFallback && (!lagMeasured || lastLag > maxReplicaLag)
+}
+
+// Measures the replica lag and notifies the high lag callback.
+func checkReplicaLag() {
+	ctx, cancel := context.WithTimeout(context.Background(), replicaLagCheckTimeout)
+	defer cancel()
+
+	lag, err := GetReplicaLag(ctx)
+
+	lagMu.Lock()
+	lagCheckActive = false
+	lastLagCheck = time.Now()
+	lastLag, lagMeasured = lag, err == nil
+	fn := highLagFn
+	if err != nil || lag <= maxReplicaLag {
+		fn = nil
+	}
+	lagMu.Unlock()
+
+	if fn != nil {
+		fn(lag)
+	}
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Waits for the background replica lag check started by replicaTooFarBehind.
+func waitLagCheck(t *testing.T) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		lagMu.Lock()
+		done := !lagCheckActive && !lastLagCheck.IsZero()
+		lagMu.Unlock()
+		if done {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("replica lag check did not finish")
+}
+
+func TestReplicaTooFarBehindWhenLagUnknown(t *testing.T) {
+	replica := openFakeDB([]string{"Seconds_Behind_Source"}, []driver.Value{int64(0)})
+	replicaErr := errors.New("replica down")
+
+	CloseDB()
+	open := openSharedPool
+	openSharedPool = func(readOnly bool) (*sql.DB, error) {
+		if readOnly && replicaErr != nil {
+			return nil, replicaErr
+		}
+		return replica, nil
+	}
+	SetMaxAllowedReplicaLag(time.Second)
+	SetReplicaLagFallback(true)
+	defer func() {
+		SetMaxAllowedReplicaLag(0)
+		SetReplicaLagFallback(false)
+		lagMu.Lock()
+		lastLag, lagMeasured, lastLagCheck = 0, false, time.Time{}
+		lagMu.Unlock()
+		CloseDB()
+		openSharedPool = open
+	}()
+
+	if !replicaTooFarBehind() {
+		t.Error("reads use the replica before its lag is measured")
+	}
+	waitLagCheck(t)
+	if !replicaTooFarBehind() {
+		t.Error("reads use the replica while its lag cannot be measured")
+	}
+
+	lagMu.Lock()
+	replicaErr, lastLagCheck = nil, time.Time{}
+	lagMu.Unlock()
+	replicaTooFarBehind()
+	waitLagCheck(t)
+	if replicaTooFarBehind() {
+		t.Error("reads use the write pool although the replica caught up")
+	}
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// A row returned by QueryAll, keyed by column name. NULL columns are absent.
+// The getters convert values with typeConvertor, Int64 with strconv, and return the zero value for missing
+// and NULL columns.
+type Row map[string]interface{}
+
+func (r Row) String(col string) string {
+	v, _ := r.convert(col, reflect.TypeOf("")).(string)
+	return v
+}
+
+func (r Row) Int64(col string) int64 {
+	v, _ := r.Int64E(col)
+	return v
+}
+
+// Same as Int64 but reports values that are not integers or do not fit in an int64,
+// such as a BIGINT UNSIGNED above math.MaxInt64. Missing and NULL columns are 0 without error.
+func (r Row) Int64E(col string) (int64, error) {
+	switch v := r[col].(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		return v, nil
+	case []byte:
+		return parseInt64(col, string(v))
+	case string:
+		return parseInt64(col, v)
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, fmt.Errorf("db: column %s value %d overflows int64", col, v)
+		}
+		return int64(v), nil
+	case uint:
+		if uint64(v) > math.MaxInt64 {
+			return 0, fmt.Errorf("db: column %s value %d overflows int64", col, v)
+		}
+		return int64(v), nil
+	default:
+		return cast.ToInt64E(v)
+	}
+}
+
+// Parses MySQL's text representation of an integer, in base 10 unlike cast.
+func parseInt64(col, s string) (int64, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("db: column %s: %w", col, err)
+	}
+	return n, nil
+}
+
+func (r Row) Float64(col string) float64 {
+	v, _ := r.convert(col, reflect.TypeOf(float64(0))).(float64)
+	return v
+}
+
+// Reports whether the column is true, non-zero integers included.
+func (r Row) Bool(col string) bool {
+	value := r[col]
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+	if v, ok := intToBool(value).(bool); ok {
+		return v
+	}
+	v, _ := r.convert(col, reflect.TypeOf(false)).(bool)
+	return v
+}
+
+func (r Row) Time(col string) time.Time {
+	v, _ := r.convert(col, reflect.TypeOf(time.Time{})).(time.Time)
+	return v
+}
+
+// Reports whether the column is NULL or missing.
+func (r Row) IsNull(col string) bool {
+	return r[col] == nil
+}
+
+func (r Row) convert(col string, t reflect.Type) interface{} {
+	value := r[col]
+	if value == nil {
+		return nil
+	}
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+	return typeConvertor(value, t)
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRowInt64(t *testing.T) {
+	row := Row{
+		"big":      []byte("9223372036854775807"),
+		"negative": []byte("-42"),
+		"unsigned": []byte("18446744073709551615"),
+		"native":   int64(math.MinInt64),
+		"uint":     uint64(math.MaxUint64),
+		"octal":    "010",
+		"text":     []byte("abc"),
+		"int":      7,
+	}
+
+	tests := []struct {
+		col     string
+		want    int64
+		wantErr bool
+	}{
+		{col: "big", want: math.MaxInt64},
+		{col: "negative", want: -42},
+		{col: "native", want: math.MinInt64},
+		{col: "octal", want: 10},
+		{col: "int", want: 7},
+		{col: "missing", want: 0},
+		{col: "unsigned", wantErr: true},
+		{col: "uint", wantErr: true},
+		{col: "text", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := row.Int64E(tt.col)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("Int64E(%q) = %d, %v, want %d (error %t)", tt.col, got, err, tt.want, tt.wantErr)
+		}
+		if got := row.Int64(tt.col); got != tt.want {
+			t.Errorf("Int64(%q) = %d, want %d", tt.col, got, tt.want)
+		}
+	}
+}
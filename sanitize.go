@@ -0,0 +1,162 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var sqlKeywords = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "WITH", "CALL", "REPLACE"}
+
+// Strips comments (`-- ...`, `# ...` and `/* ... */`) outside of quoted strings and identifiers, collapses
+// whitespace to single spaces and checks that the result is a single DML statement starting with
+// SELECT, INSERT, UPDATE, DELETE, REPLACE, WITH or CALL, with balanced quotes and parentheses.
+// Optimizer hints (`/*+ ... */`) and executable comments (`/*! ... */`) are kept, as MySQL runs them.
+//
+// This is a guard against comment injection and obviously malformed SQL, not a parser.
+func SanitizeSQL(query string) (string, error) {
+	var (
+		b     strings.Builder
+		depth int
+		space bool
+		semi  bool // a statement separator was seen
+	)
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case semi && c != ';' && !isSpace(c) && c != '#' && c != '-' && c != '/':
+			return "", errors.New("db: multiple statements are not allowed")
+		case c == ';':
+			semi = true
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			end, err := quotedEnd(query, i)
+			if err != nil {
+				return "", err
+			}
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(query[i : end+1])
+			i = end
+			space = false
+			continue
+		case c == '#' || (c == '-' && strings.HasPrefix(query[i:], "--") && (i+2 == len(query) || isSpace(query[i+2]))):
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(query)
+			}
+			space = true
+			continue
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return "", errors.New("db: unterminated comment")
+			}
+			comment := query[i : i+end+4]
+			i += end + 3
+			if !strings.HasPrefix(comment, "/*+") && !strings.HasPrefix(comment, "/*!") {
+				space = true
+				continue
+			}
+
+			if strings.IndexByte(comment, ';') >= 0 {
+				return "", errors.New("db: multiple statements are not allowed")
+			}
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(strings.Join(strings.Fields(comment), " "))
+			space = false
+			continue
+		case isSpace(c):
+			space = true
+			continue
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth < 0 {
+				return "", errors.New("db: unbalanced parentheses")
+			}
+		}
+
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteByte(c)
+	}
+
+	if depth != 0 {
+		return "", errors.New("db: unbalanced parentheses")
+	}
+
+	res := b.String()
+	keyword, _, _ := strings.Cut(trimLeadingComments(res), " ")
+	keyword = strings.ToUpper(strings.TrimLeft(keyword, "("))
+	if IndexOf(keyword, sqlKeywords) < 0 {
+		return "", fmt.Errorf("db: query must start with one of %s", strings.Join(sqlKeywords, ", "))
+	}
+	return res, nil
+}
+
+// Returns the index of the quote closing the string or identifier opened at start.
+// A doubled quote or, outside identifiers, a backslash escapes the next character.
+func quotedEnd(query string, start int) (int, error) {
+	quote := query[start]
+	for i := start + 1; i < len(query); i++ {
+		switch {
+		case query[i] == '\\' && quote != '`':
+			i++
+		case query[i] == quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i, nil
+		}
+	}
+	return 0, errors.New("db: unterminated quoted string")
+}
+
+// Returns the index of the last byte of the quoted string, identifier or comment starting at start, if any,
+// for the placeholder rewriters that must leave them untouched.
+func literalEnd(query string, start int) (int, bool, error) {
+	switch c := query[start]; {
+	case c == '\'' || c == '"' || c == '`':
+		end, err := quotedEnd(query, start)
+		return end, err == nil, err
+	case c == '#' || (c == '-' && strings.HasPrefix(query[start:], "--") && (start+2 == len(query) || isSpace(query[start+2]))):
+		if end := strings.IndexByte(query[start:], '\n'); end >= 0 {
+			return start + end - 1, true, nil
+		}
+		return len(query) - 1, true, nil
+	case c == '/' && strings.HasPrefix(query[start:], "/*"):
+		end := strings.Index(query[start+2:], "*/")
+		if end < 0 {
+			return 0, false, errors.New("db: unterminated comment")
+		}
+		return start + end + 3, true, nil
+	}
+	return 0, false, nil
+}
+
+// Returns query without its leading whitespace and `/* ... */` comments.
+func trimLeadingComments(query string) string {
+	for {
+		query = strings.TrimLeft(query, " \t\n\r\f\v")
+		if !strings.HasPrefix(query, "/*") {
+			return query
+		}
+		end := strings.Index(query[2:], "*/")
+		if end < 0 {
+			return query
+		}
+		query = query[end+4:]
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
@@ -0,0 +1,36 @@
+package db
+
+import "testing"
+
+func TestSanitizeSQL(t *testing.T) {
+	tests := []struct {
+		query, want string
+		wantErr     bool
+	}{
+		{query: "SELECT  *\n FROM t -- trailing\n WHERE id = 1", want: "SELECT * FROM t WHERE id = 1"},
+		{query: "SELECT /* note */ * FROM t", want: "SELECT * FROM t"},
+		{query: "SELECT /*+ MAX_EXECUTION_TIME(1000)\n */ * FROM t", want: "SELECT /*+ MAX_EXECUTION_TIME(1000) */ * FROM t"},
+		{query: "/*!40001 SQL_NO_CACHE */ SELECT 1", want: "/*!40001 SQL_NO_CACHE */ SELECT 1"},
+		{query: "/* app */ SELECT 1", want: "SELECT 1"},
+		{query: "SELECT '/* kept */' FROM t", want: "SELECT '/* kept */' FROM t"},
+		{query: "SELECT /*! 1; DROP TABLE t */ 1", wantErr: true},
+		{query: "SELECT 1; DROP TABLE t", wantErr: true},
+		{query: "/*!40001 DROP TABLE t */", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := SanitizeSQL(tt.query)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("SanitizeSQL(%q) = %q, %v, want %q (error %t)", tt.query, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestBindNamedDoubleDashNeedsSpace(t *testing.T) {
+	query, args, err := BindNamed("UPDATE t SET n = n--:delta WHERE id = :id -- :ignored", map[string]interface{}{"delta": 1, "id": 2})
+	if err != nil {
+		t.Fatalf("BindNamed error = %v", err)
+	}
+	if want := "UPDATE t SET n = n--? WHERE id = ? -- :ignored"; query != want || len(args) != 2 {
+		t.Errorf("BindNamed = %q, %v, want %q with 2 args", query, args, want)
+	}
+}
@@ -0,0 +1,519 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	scannerType  = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	rawBytesType = reflect.TypeOf(sql.RawBytes(nil))
+)
+
+// Scans rows into T, working out the column to field mapping once per result set and reusing
+// the scan buffers between rows. A StructScanner is not safe for concurrent use.
+//
+// sql.RawBytes fields point into the driver's buffers and are only valid until the next call to Scan
+// or rows.Next. The package functions returning rows copy them.
+type StructScanner[T any] struct {
+	rows    *sql.Rows
+	fields  []string
+	types   []*sql.ColumnType
+	scans   []interface{}
+	targets []scanTarget
+
+	copyRawBytes bool
+}
+
+// A struct field and the column it is scanned from.
+type scanTarget struct {
+	field    int
+	column   int
+	holder   interface{} // intermediate value for fields that cannot hold NULL by themselves, nil to scan directly
+	duration bool        // TIME column read into a time.Duration field tagged with the duration option
+	rawBytes bool        // sql.RawBytes field scanned in place
+}
+
+func NewStructScanner[T any]() *StructScanner[T] {
+	return &StructScanner[T]{}
+}
+
+// Same as NewStructScanner but copies sql.RawBytes fields, for rows kept after the next call to rows.Next.
+func newRowScanner[T any]() *StructScanner[T] {
+	return &StructScanner[T]{copyRawBytes: true}
+}
+
+// Idle scanners per struct type, so ScanStruct called row by row keeps its column mapping and buffers.
+var scannerPools sync.Map // reflect.Type -> *sync.Pool
+
+func getScanner[T any]() (*StructScanner[T], *sync.Pool) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	pool, ok := scannerPools.Load(t)
+	if !ok {
+		pool, _ = scannerPools.LoadOrStore(t, &sync.Pool{
+			New: func() interface{} { return newRowScanner[T]() },
+		})
+	}
+	p := pool.(*sync.Pool)
+	return p.Get().(*StructScanner[T]), p
+}
+
+// Drops the references to the last scanned row and its result set so nothing leaks into the next user
+// of the scanner. The column mapping is kept for the next result set of the same shape.
+func (s *StructScanner[T]) reset() {
+	s.rows = nil
+
+	// Field pointers into the returned struct are dropped, not zeroed
+	for _, t := range s.targets {
+		if t.holder == nil {
+			s.scans[t.column] = nil
+		}
+	}
+	// Holders and the slots of discarded columns
+	for _, scan := range s.scans {
+		if scan != nil {
+			h := reflect.ValueOf(scan).Elem()
+			h.Set(reflect.Zero(h.Type()))
+		}
+	}
+}
+
+// Scans the current row of rows into a new T, like ScanStruct.
+func (s *StructScanner[T]) Scan(rows *sql.Rows) (structData T, err error) {
+	if rows != s.rows {
+		if err := s.prepare(rows); err != nil {
+			return structData, err
+		}
+	}
+
+	rv := reflect.ValueOf(&structData).Elem()
+	for _, t := range s.targets {
+		if t.holder == nil {
+			s.scans[t.column] = rv.Field(t.field).Addr().Interface()
+		}
+	}
+
+	if err = rows.Scan(s.scans...); err != nil {
+		return structData, &ScanError{Row: -1, Column: failingColumn(rows, s.scans, s.fields), Err: err}
+	}
+
+	for _, t := range s.targets {
+		if t.rawBytes && s.copyRawBytes {
+			field := rv.Field(t.field)
+			if b := field.Bytes(); b != nil {
+				field.SetBytes(append(make([]byte, 0, len(b)), b...))
+			}
+		}
+		if t.holder == nil {
+			continue
+		}
+
+		field := rv.Field(t.field)
+		value := holderValue(t.holder)
+		if isEmptyInterface(field.Type()) {
+			value = typedValue(s.types[t.column].DatabaseTypeName(), value)
+		}
+		if t.duration && value != nil {
+			d, err := parseTimeColumn(value)
+			if err != nil {
+				return structData, &ScanError{Row: -1, Column: s.fields[t.column], Err: err}
+			}
+			if field.Kind() == reflect.Ptr {
+				field.Set(reflect.New(durationType))
+				field = field.Elem()
+			}
+			field.SetInt(int64(d))
+			continue
+		}
+		if err := setFieldFromInterface(field, value); err != nil {
+			err = fmt.Errorf("%s into %s: %w", s.types[t.column].DatabaseTypeName(), field.Type(), err)
+			return structData, &ScanError{Row: -1, Column: s.fields[t.column], Err: err}
+		}
+	}
+
+	return structData, nil
+}
+
+// Maps the columns of rows to the fields of T and allocates the scan buffers.
+func (s *StructScanner[T]) prepare(rows *sql.Rows) error {
+	fields, err := rows.Columns() // fieldName
+	if err != nil {
+		return err
+	}
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	// A pooled scanner keeps its mapping and buffers for result sets of the same shape
+	if sameColumns(fields, s.fields) && sameColumnTypes(types, s.types) {
+		s.rows, s.types = rows, types
+		return nil
+	}
+
+	// Unmapped columns are discarded into their own slot
+	scans := make([]interface{}, len(fields))
+	for i := range scans {
+		scans[i] = new(interface{})
+	}
+
+	var targets []scanTarget
+	rt := reflect.TypeOf((*T)(nil)).Elem()
+	for i := 0; i < rt.NumField(); i++ {
+		fieldName := columnName(rt.Field(i))
+		idx := IndexOf(fieldName, fields)
+		if fieldName != "" && idx < 0 {
+			idx = IndexOf(toSnake(rt.Field(i).Name), fields)
+		}
+		if fieldName != "" && idx < 0 {
+			// userid column for a UserID field, the mapping of earlier versions
+			idx = IndexOf(strings.ToLower(rt.Field(i).Name), fields)
+		}
+
+		if fieldName == "" || idx < 0 {
+			continue
+		}
+
+		target := scanTarget{field: i, column: idx}
+		fieldType := rt.Field(i).Type
+		nullable, ok := types[idx].Nullable()
+		switch {
+		case fieldType == rawBytesType:
+			// Scanned in place, only valid until the next call to Scan or rows.Next unless copied
+			target.rawBytes = true
+		case hasTagOption(rt.Field(i), "duration") && (fieldType == durationType || fieldType == reflect.PtrTo(durationType)):
+			target.holder = new(interface{})
+			target.duration = true
+		case hasConverter(fieldType):
+			// Registered converters receive the raw value after scanning
+			target.holder = new(interface{})
+		case isEmptyInterface(fieldType):
+			// Typed from the column type after scanning instead of keeping the driver's []byte
+			target.holder = new(interface{})
+		case isEnumType(fieldType):
+			// Registered enums are validated after scanning
+			target.holder = new(interface{})
+		case isBoolType(fieldType) && isNumericColumn(types[idx].DatabaseTypeName()):
+			// TINYINT(1) booleans come back as integers, any non-zero value is true
+			target.holder = new(interface{})
+		case ok && nullable && !isNullableType(fieldType):
+			// A NULL can only be scanned straight into fields able to hold it
+			target.holder = scanHolder(types[idx], fieldType)
+		}
+		if target.holder != nil {
+			scans[idx] = target.holder
+		}
+		targets = append(targets, target)
+	}
+
+	s.rows, s.fields, s.types, s.scans, s.targets = rows, fields, types, scans, targets
+	return nil
+}
+
+// Reports whether both result sets have columns of the same database types and nullability.
+func sameColumnTypes(a, b []*sql.ColumnType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].DatabaseTypeName() != b[i].DatabaseTypeName() {
+			return false
+		}
+		an, aok := a[i].Nullable()
+		bn, bok := b[i].Nullable()
+		if an != bn || aok != bok {
+			return false
+		}
+	}
+	return true
+}
+
+// Reports whether a field of type t can receive NULL straight from rows.Scan.
+func isNullableType(t reflect.Type) bool {
+	if t == rawBytesType {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return reflect.PtrTo(t).Implements(scannerType)
+}
+
+// Returns the intermediate scan target for a nullable column read into a field of type t.
+func scanHolder(col *sql.ColumnType, t reflect.Type) interface{} {
+	numeric := isNumericColumn(col.DatabaseTypeName())
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if numeric {
+			return new(sql.NullInt64)
+		}
+	case reflect.Float32, reflect.Float64:
+		if numeric {
+			return new(sql.NullFloat64)
+		}
+	case reflect.Bool:
+		return new(sql.NullBool)
+	case reflect.String:
+		return new(sql.NullString)
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return new(sql.NullTime)
+		}
+	}
+	return new(interface{})
+}
+
+// Returns the value held by an intermediate scan target, nil for NULL.
+func holderValue(holder interface{}) interface{} {
+	switch h := holder.(type) {
+	case *sql.NullInt64:
+		if h.Valid {
+			return h.Int64
+		}
+	case *sql.NullFloat64:
+		if h.Valid {
+			return h.Float64
+		}
+	case *sql.NullBool:
+		if h.Valid {
+			return h.Bool
+		}
+	case *sql.NullString:
+		if h.Valid {
+			return h.String
+		}
+	case *sql.NullTime:
+		if h.Valid {
+			return h.Time
+		}
+	case *interface{}:
+		return *h
+	}
+	return nil
+}
+
+// Reports whether t is bool or *bool.
+func isBoolType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
+}
+
+// Turns the integers MySQL uses for TINYINT(1) booleans into a bool, any non-zero value being true.
+// Other values are returned unchanged.
+func intToBool(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int64:
+		return v != 0
+	case uint64:
+		return v != 0
+	case string:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n != 0
+		}
+	}
+	return value
+}
+
+func isNumericColumn(databaseType string) bool {
+	switch strings.TrimPrefix(strings.ToUpper(databaseType), "UNSIGNED ") {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "YEAR", "DECIMAL", "FLOAT", "DOUBLE", "BIT":
+		return true
+	}
+	return false
+}
+
+// Converts the raw []byte the driver returns for most columns to a Go value matching the column type:
+// int64 or uint64 for integers, float64 for decimals and floats, time.Time for dates and string for text.
+// Binary columns and values that are not []byte are returned unchanged.
+func typedValue(databaseType string, value interface{}) interface{} {
+	b, ok := value.([]byte)
+	if !ok {
+		return value
+	}
+
+	dbType := strings.ToUpper(databaseType)
+	switch strings.TrimPrefix(dbType, "UNSIGNED ") {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "YEAR":
+		if n, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+			return n
+		}
+		if n, err := strconv.ParseUint(string(b), 10, 64); err == nil {
+			return n
+		}
+	case "DECIMAL", "FLOAT", "DOUBLE":
+		if f, err := strconv.ParseFloat(string(b), 64); err == nil {
+			return f
+		}
+	case "DATETIME", "TIMESTAMP", "DATE":
+		for _, layout := range []string{"2006-01-02 15:04:05.999999", "2006-01-02"} {
+			if t, err := time.ParseInLocation(layout, string(b), time.UTC); err == nil {
+				return t
+			}
+		}
+	case "CHAR", "VARCHAR", "TINYTEXT", "TEXT", "MEDIUMTEXT", "LONGTEXT", "ENUM", "SET", "JSON", "TIME":
+		return string(b)
+	}
+	return value
+}
+
+// Reports whether t is an empty interface such as interface{} or any.
+func isEmptyInterface(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface && t.NumMethod() == 0
+}
+
+// Assigns a scanned value to field, converting it with typeConvertor. NULL resets the field to its zero value.
+func setFieldFromInterface(field reflect.Value, value interface{}) error {
+	if value == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	if fn, ok := converterFor(field.Type()); ok {
+		converted, err := fn(value)
+		if err != nil {
+			return err
+		}
+		return assignValue(field, converted, value)
+	}
+
+	if field.Kind() == reflect.Ptr {
+		elem := reflect.New(field.Type().Elem())
+		if err := setFieldFromInterface(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	if b, ok := value.([]byte); ok && field.Type() != reflect.TypeOf(b) && !isEmptyInterface(field.Type()) {
+		value = string(b)
+	}
+
+	if field.Kind() == reflect.Bool {
+		value = intToBool(value)
+	}
+
+	if isEnumType(field.Type()) {
+		s, err := enumValue(field.Type(), value)
+		if err != nil {
+			return err
+		}
+		field.SetString(s)
+		return nil
+	}
+
+	return assignValue(field, typeConvertor(value, field.Type()), value)
+}
+
+// Assigns converted, obtained from the scanned value src, to field. A nil value resets the field.
+func assignValue(field reflect.Value, converted interface{}, src interface{}) error {
+	cv := reflect.ValueOf(converted)
+	switch {
+	case !cv.IsValid():
+		field.Set(reflect.Zero(field.Type()))
+	case cv.Type().AssignableTo(field.Type()):
+		field.Set(cv)
+	case cv.Type().ConvertibleTo(field.Type()):
+		field.Set(cv.Convert(field.Type()))
+	default:
+		return fmt.Errorf("cannot assign %T to %s", src, field.Type())
+	}
+	return nil
+}
+
+// Describes a row that could not be scanned. Row is the zero-based row index, or -1 when unknown.
+type ScanError struct {
+	Row    int
+	Column string
+	Err    error
+}
+
+func (e *ScanError) Error() string {
+	msg := "db: scan"
+	if e.Row >= 0 {
+		msg += fmt.Sprintf(" row %d", e.Row)
+	}
+	if e.Column != "" {
+		msg += fmt.Sprintf(" column %q", e.Column)
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
+
+// Finds the column rows.Scan failed on by scanning the targets one at a time, all other columns discarded.
+// Returns an empty string when no single column fails on its own.
+func failingColumn(rows *sql.Rows, scans []interface{}, fields []string) string {
+	for i := range scans {
+		if _, discard := scans[i].(*interface{}); discard {
+			continue
+		}
+
+		single := make([]interface{}, len(scans))
+		for j := range single {
+			single[j] = new(interface{})
+		}
+		single[i] = scans[i]
+
+		if rows.Scan(single...) != nil {
+			return fields[i]
+		}
+	}
+	return ""
+}
+
+// Executes the query and returns every row that could be scanned, together with the rows that could not.
+// A bad row does not stop the iteration, only query and connection errors are returned as err.
+func AllWithError[T any](ctx context.Context, query string, args []interface{}) ([]T, []ScanError, error) {
+	defer timer(query, args)()
+
+	db, err := dbFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := queryRows(ctx, db, query, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var (
+		res      []T
+		scanErrs []ScanError
+	)
+	scanner := newRowScanner[T]()
+	for i := 0; rows.Next(); i++ {
+		structData, err := scanner.Scan(rows)
+		if err != nil {
+			scanErr := ScanError{Row: i, Err: err}
+			var se *ScanError
+			if errors.As(err, &se) {
+				scanErr.Column, scanErr.Err = se.Column, se.Err
+			}
+			scanErrs = append(scanErrs, scanErr)
+			continue
+		}
+		res = append(res, structData)
+	}
+
+	return res, scanErrs, ClassifyError(rows.Err())
+}
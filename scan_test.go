@@ -0,0 +1,71 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestKeptRowsCopyRawBytes(t *testing.T) {
+	type blob struct {
+		Data sql.RawBytes `db:"data"`
+	}
+
+	buf := []byte("first")
+	pool := openFakeDB([]string{"data"}, []driver.Value{buf})
+	useSharedPool(t, pool)
+
+	rows, err := All[blob]("SELECT data FROM t", nil)
+	if err != nil {
+		t.Fatalf("All error = %v", err)
+	}
+	one, err := One[blob]("SELECT data FROM t", nil)
+	if err != nil {
+		t.Fatalf("One error = %v", err)
+	}
+
+	// The driver reuses its buffer for the next row
+	copy(buf, "later")
+	if len(rows) != 1 || string(rows[0].Data) != "first" {
+		t.Errorf("All row = %q, want a copy of the scanned bytes", rows)
+	}
+	if one == nil || string(one.Data) != "first" {
+		t.Errorf("One row = %v, want a copy of the scanned bytes", one)
+	}
+}
+
+func TestPooledScannerResetDropsRow(t *testing.T) {
+	type user struct {
+		ID int64 `db:"id"`
+	}
+
+	pool := openFakeDB([]string{"id", "secret"}, []driver.Value{int64(1), []byte("hunter2")})
+	defer pool.Close()
+
+	rows, err := pool.Query("SELECT id, secret FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	scanner := newRowScanner[user]()
+	if !rows.Next() {
+		t.Fatal("no row")
+	}
+	if _, err := scanner.Scan(rows); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner.reset()
+	if scanner.rows != nil {
+		t.Error("reset kept the result set")
+	}
+	for i, scan := range scanner.scans {
+		if p, ok := scan.(*interface{}); ok && *p != nil {
+			t.Errorf("reset kept %v in scan slot %d", *p, i)
+		}
+		if scan != nil && i == 0 {
+			t.Errorf("reset kept the field pointer of the returned row")
+		}
+	}
+}
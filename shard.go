@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Connection settings of a single shard. Read is optional, reads use Write when it is nil.
+type ShardConfig struct {
+	Name  string
+	Write Config
+	Read  *Config
+}
+
+// Routes queries to one of several horizontally sharded databases based on a shard key.
+type ShardedDB struct {
+	shards  []*NamedDB
+	shardFn func(key interface{}) int
+}
+
+// Opens every shard and returns a ShardedDB routing keys with shardFn,
+// which must return an index into shards.
+func NewShardedDB(shards []ShardConfig, shardFn func(key interface{}) int) (*ShardedDB, error) {
+	s := &ShardedDB{shardFn: shardFn}
+	for _, shard := range shards {
+		var read []Config
+		if shard.Read != nil {
+			read = append(read, *shard.Read)
+		}
+
+		d, err := OpenNamedDB(shard.Name, shard.Write, read...)
+		if err != nil {
+			s.Close()
+			return nil, fmt.Errorf("db: open shard %q: %w", shard.Name, err)
+		}
+		s.shards = append(s.shards, d)
+	}
+	return s, nil
+}
+
+// Returns the shard that owns key, or an error when the shard func returns an index out of range.
+func (s *ShardedDB) ForShard(key interface{}) (*NamedDB, error) {
+	idx := s.shardFn(key)
+	if idx < 0 || idx >= len(s.shards) {
+		return nil, fmt.Errorf("db: shard index %d for key %v out of range [0, %d)", idx, key, len(s.shards))
+	}
+	return s.shards[idx], nil
+}
+
+// Executes the statement on the write pool of the shard that owns key.
+func (s *ShardedDB) Exec(key interface{}, query string, args []interface{}) (sql.Result, error) {
+	shard, err := s.ForShard(key)
+	if err != nil {
+		return nil, err
+	}
+	return shard.Exec(query, args)
+}
+
+// Closes every shard.
+func (s *ShardedDB) Close() error {
+	var errs []error
+	for _, shard := range s.shards {
+		errs = append(errs, shard.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Same as One but runs on the shard that owns key.
+func ShardOne[T any](s *ShardedDB, key interface{}, query string, args []interface{}) (*T, error) {
+	shard, err := s.ForShard(key)
+	if err != nil {
+		return nil, err
+	}
+	return OneOn[T](shard, query, args)
+}
+
+// Same as All but runs on the shard that owns key.
+func ShardAll[T any](s *ShardedDB, key interface{}, query string, args []interface{}) ([]T, error) {
+	shard, err := s.ForShard(key)
+	if err != nil {
+		return nil, err
+	}
+	return AllOn[T](shard, query, args)
+}
+
+// The errors of the shards that failed in AllParallel, in shard order.
+type MultiError []error
+
+func (m MultiError) Error() string {
+	msgs := make([]string, len(m))
+	for i, err := range m {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("db: %d errors: %s", len(m), strings.Join(msgs, "; "))
+}
+
+func (m MultiError) Unwrap() []error {
+	return m
+}
+
+// Executes the same query concurrently on every pool in dbs and merges the rows in pool order.
+// When keyFn is given, only the first row for each key is kept.
+//
+// The rows of the pools that succeeded are returned together with a MultiError listing the failures.
+func AllParallel[T any](ctx context.Context, dbs []*sql.DB, query string, args []interface{}, keyFn ...func(T) interface{}) ([]T, error) {
+	results := make([][]T, len(dbs))
+	errs := make([]error, len(dbs))
+
+	var wg sync.WaitGroup
+	for i, db := range dbs {
+		wg.Add(1)
+		go func(i int, db *sql.DB) {
+			defer wg.Done()
+			results[i], errs[i] = allFrom[T](ctx, db, query, args)
+		}(i, db)
+	}
+	wg.Wait()
+
+	var (
+		res      []T
+		multiErr MultiError
+		seen     = map[interface{}]struct{}{}
+	)
+	for i, rows := range results {
+		if errs[i] != nil {
+			multiErr = append(multiErr, fmt.Errorf("shard %d: %w", i, errs[i]))
+			continue
+		}
+
+		for _, row := range rows {
+			if len(keyFn) > 0 {
+				key := keyFn[0](row)
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+			}
+			res = append(res, row)
+		}
+	}
+
+	if len(multiErr) > 0 {
+		return res, multiErr
+	}
+	return res, nil
+}
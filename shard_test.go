@@ -0,0 +1,27 @@
+package db
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+func TestShardOutOfRangeIsAnError(t *testing.T) {
+	pool := &lazyPool{db: openFakeDB([]string{"id"}, []driver.Value{int64(1)})}
+	s := &ShardedDB{
+		shards:  []*NamedDB{{Name: "0", read: pool, write: pool}},
+		shardFn: func(key interface{}) int { return key.(int) },
+	}
+
+	if _, err := ShardOne[struct{ ID int64 }](s, 0, "SELECT id FROM t", nil); err != nil {
+		t.Fatalf("ShardOne error = %v", err)
+	}
+	if _, err := s.ForShard(1); err == nil {
+		t.Error("ForShard(1) succeeded, want an error")
+	}
+	if _, err := ShardAll[struct{ ID int64 }](s, -1, "SELECT id FROM t", nil); err == nil {
+		t.Error("ShardAll(-1) succeeded, want an error")
+	}
+	if _, err := s.Exec(2, "DELETE FROM t", nil); err == nil {
+		t.Error("Exec(2) succeeded, want an error")
+	}
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type SortDir string
+
+const (
+	Asc  SortDir = "ASC"
+	Desc SortDir = "DESC"
+)
+
+// A user controlled sort order. Column must be one of Allowed, Direction defaults to Asc.
+type SortSpec struct {
+	Column    string
+	Direction SortDir
+	Allowed   []string
+}
+
+// Returns the ORDER BY clause for the spec, or an error if the column or direction is not allowed.
+func (s SortSpec) orderBy() (string, error) {
+	if IndexOf(s.Column, s.Allowed) < 0 {
+		return "", fmt.Errorf("db: sorting by %q is not allowed", s.Column)
+	}
+
+	dir := SortDir(strings.ToUpper(string(s.Direction)))
+	switch dir {
+	case "":
+		dir = Asc
+	case Asc, Desc:
+	default:
+		return "", fmt.Errorf("db: invalid sort direction %q", s.Direction)
+	}
+
+	return fmt.Sprintf("ORDER BY %s %s", quoteIdent(s.Column), dir), nil
+}
+
+// Executes the query sorted by a user supplied column. The column is checked against sort.Allowed
+// before `ORDER BY` is appended, so it can safely come from request parameters.
+func AllSorted[T any](query string, args []interface{}, sort SortSpec) ([]T, error) {
+	orderBy, err := sort.orderBy()
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.TrimRight(query, "; \t\r\n") + " " + orderBy
+	return allContext[T](context.Background(), query, args)
+}
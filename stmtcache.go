@@ -0,0 +1,198 @@
+package db
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+)
+
+// Lazily prepares statements and keeps them per pool and query string,
+// evicting the least recently used statement once the limit is reached.
+// An evicted statement is closed once the callers still using it have released it.
+type StmtCache struct {
+	mu    sync.Mutex
+	max   int // 0 means unlimited
+	items map[stmtKey]*list.Element
+	lru   *list.List
+}
+
+type stmtKey struct {
+	db    *sql.DB
+	query string
+}
+
+type stmtEntry struct {
+	key     stmtKey
+	stmt    *sql.Stmt
+	refs    int  // callers between Get and release
+	evicted bool // no longer in the cache, closed by the last release
+}
+
+var (
+	stmtCache          = NewStmtCache(100)
+	preparedStatements atomic.Bool
+)
+
+// Returns an empty cache holding at most max statements, or any number of them when max is 0.
+func NewStmtCache(max int) *StmtCache {
+	return &StmtCache{
+		max:   max,
+		items: map[stmtKey]*list.Element{},
+		lru:   list.New(),
+	}
+}
+
+// Returns the cached statement for query on db, preparing it on first access, and a func to call
+// once, after the statement has been run, so it is not closed in the meantime by an eviction.
+// Rows returned by the statement stay readable after the release.
+func (c *StmtCache) Get(db *sql.DB, query string) (*sql.Stmt, func(), error) {
+	key := stmtKey{db: db, query: query}
+
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		c.lru.MoveToFront(el)
+		entry := c.acquire(el)
+		c.mu.Unlock()
+		return entry.stmt, func() { c.release(entry) }, nil
+	}
+	c.mu.Unlock()
+
+	// Prepare outside the lock, it is a round trip to MySQL
+	stmt, err := db.Prepare(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		stmt.Close()
+		c.lru.MoveToFront(el)
+		entry := c.acquire(el)
+		return entry.stmt, func() { c.release(entry) }, nil
+	}
+
+	el := c.lru.PushFront(&stmtEntry{key: key, stmt: stmt})
+	c.items[key] = el
+	entry := c.acquire(el)
+	c.evict()
+	return stmt, func() { c.release(entry) }, nil
+}
+
+// Must be called with c.mu held.
+func (c *StmtCache) acquire(el *list.Element) *stmtEntry {
+	entry := el.Value.(*stmtEntry)
+	entry.refs++
+	return entry
+}
+
+func (c *StmtCache) release(entry *stmtEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// Forgets the entry and closes its statement unless it is still in use. Must be called with c.mu held.
+func (c *StmtCache) remove(el *list.Element) {
+	entry := c.lru.Remove(el).(*stmtEntry)
+	delete(c.items, entry.key)
+	entry.evicted = true
+	if entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// Changes the maximum number of cached statements, 0 means unlimited.
+func (c *StmtCache) SetMax(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.max = n
+	c.evict()
+}
+
+// Closes and forgets every cached statement.
+func (c *StmtCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, el := range c.items {
+		c.remove(el)
+	}
+}
+
+// Closes and forgets the cached statements of db, e.g. before the pool is closed.
+func (c *StmtCache) Forget(db *sql.DB) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, el := range c.items {
+		if key.db == db {
+			c.remove(el)
+		}
+	}
+}
+
+// Must be called with c.mu held.
+func (c *StmtCache) evict() {
+	for c.max > 0 && c.lru.Len() > c.max {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Makes Exec and the query functions run through cached prepared statements.
+func EnablePreparedStatements() {
+	preparedStatements.Store(true)
+}
+
+func DisablePreparedStatements() {
+	preparedStatements.Store(false)
+	stmtCache.Clear()
+}
+
+// Closes every cached prepared statement.
+func ClearStmtCache() {
+	stmtCache.Clear()
+}
+
+// Limits the number of cached prepared statements (default 100, 0 means unlimited).
+func SetMaxCachedStmts(n int) {
+	stmtCache.SetMax(n)
+}
+
+// Runs the query on db, through a cached prepared statement when they are enabled.
+func cachedQuery(ctx context.Context, db *sql.DB, query string, args []interface{}) (*sql.Rows, error) {
+	if !preparedStatements.Load() {
+		return db.QueryContext(ctx, query, args...)
+	}
+
+	stmt, release, err := stmtCache.Get(db, query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return stmt.QueryContext(ctx, args...)
+}
+
+// Executes the statement on db, through a cached prepared statement when they are enabled.
+func cachedExec(ctx context.Context, db *sql.DB, query string, args []interface{}) (sql.Result, error) {
+	if !preparedStatements.Load() {
+		return db.ExecContext(ctx, query, args...)
+	}
+
+	stmt, release, err := stmtCache.Get(db, query)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return stmt.ExecContext(ctx, args...)
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestStmtCacheKeepsStatementsInUse(t *testing.T) {
+	pool := openFakeDB(nil)
+	cache := NewStmtCache(4)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			stmt, release, err := cache.Get(pool, fmt.Sprintf("UPDATE t SET n = n + 1 /* %d */", i))
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer release()
+
+			// Give the other goroutines time to evict the statement
+			runtime.Gosched()
+			if _, err := stmt.ExecContext(context.Background()); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("exec error = %v", err)
+	}
+
+	cache.Clear()
+	if n := cache.lru.Len(); n != 0 {
+		t.Errorf("%d statements cached after Clear, want 0", n)
+	}
+}
@@ -0,0 +1,185 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Reports whether a struct field should be kept in a column list.
+type ColumnFilter func(field reflect.StructField, column string) bool
+
+// Drops the fields tagged `db:",readonly"`, e.g. generated columns.
+func ExcludeReadonly() ColumnFilter {
+	return func(field reflect.StructField, column string) bool {
+		return !hasTagOption(field, "readonly")
+	}
+}
+
+// Drops the created_at and updated_at columns maintained by MySQL.
+func ExcludeAutoTimestamps() ColumnFilter {
+	return func(field reflect.StructField, column string) bool {
+		switch column {
+		case "created_at", "updated_at", "createdat", "updatedat":
+			return false
+		}
+		return true
+	}
+}
+
+// Returns the column names of T in field order, using the same tag rules as ScanStruct.
+func ColumnNames[T any](filters ...ColumnFilter) []string {
+	var cols []string
+	eachColumn(reflect.TypeOf((*T)(nil)).Elem(), filters, func(i int, field reflect.StructField, col string) {
+		cols = append(cols, col)
+	})
+	return cols
+}
+
+// Returns the quoted, comma-separated column list of T for a SELECT statement, minus the
+// columns (or field names) in excludeFields, e.g. "`id`, `name`, `email`".
+func SelectFields[T any](excludeFields ...string) string {
+	var cols []string
+	eachColumn(reflect.TypeOf((*T)(nil)).Elem(), nil, func(i int, field reflect.StructField, col string) {
+		if IndexOf(col, excludeFields) >= 0 || IndexOf(field.Name, excludeFields) >= 0 {
+			return
+		}
+		cols = append(cols, quoteIdent(col))
+	})
+	return strings.Join(cols, ", ")
+}
+
+// Returns the field values of row in the same order as ColumnNames[T] with the same filters.
+// Pointer fields are dereferenced and nil pointers become nil.
+func StructValues[T any](row T, filters ...ColumnFilter) ([]interface{}, error) {
+	rv := reflect.ValueOf(row)
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("db: expected a struct, got %T", row)
+	}
+
+	var values []interface{}
+	eachColumn(rv.Type(), filters, func(i int, field reflect.StructField, col string) {
+		value := rv.Field(i)
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				values = append(values, nil)
+				return
+			}
+			value = value.Elem()
+		}
+		values = append(values, value.Interface())
+	})
+	return values, nil
+}
+
+// Returns the fields of row keyed by column name, using the same tag rules as ScanStruct.
+// Nil pointers are omitted and other pointers dereferenced. Zero values are omitted unless includeZero is set.
+// Returns nil when row is a nil pointer or not a struct.
+func ConvertStructToMap[T any](row T, includeZero bool) map[string]interface{} {
+	rv := reflect.Indirect(reflect.ValueOf(row))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	res := map[string]interface{}{}
+	eachColumn(rv.Type(), nil, func(i int, field reflect.StructField, col string) {
+		value := rv.Field(i)
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return
+			}
+			value = value.Elem()
+		}
+		if !includeZero && value.IsZero() {
+			return
+		}
+		res[col] = value.Interface()
+	})
+	return res
+}
+
+// Returns the column name of a struct field.
+//
+// The name comes from the `db` tag, then the `json` tag, and falls back to the snake_case field name,
+// e.g. user_id for UserID.
+// An empty string means the field is not mapped to a column.
+func columnName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+
+	for _, key := range []string{"db", "json"} {
+		tag, ok := field.Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		switch {
+		case name == "-":
+			return ""
+		case name == "", key == "db" && strings.HasPrefix(strings.TrimSpace(name), "type:"):
+			// `db:"type:..."` only sets the column type for CreateTableSQL
+			continue
+		}
+		return name
+	}
+
+	return toSnake(field.Name)
+}
+
+// Converts a Go identifier to snake_case, e.g. UserID to user_id and HTTPServer to http_server.
+func toSnake(name string) string {
+	runes := []rune(name)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Reports whether the `db` tag of field carries option, e.g. `db:"id,pk"`.
+func hasTagOption(field reflect.StructField, option string) bool {
+	_, opts, _ := strings.Cut(field.Tag.Get("db"), ",")
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if strings.TrimSpace(opt) == option {
+			return true
+		}
+	}
+	return false
+}
+
+// Calls fn for every field of the struct type t that is mapped to a column and kept by filters.
+func eachColumn(t reflect.Type, filters []ColumnFilter, fn func(i int, field reflect.StructField, col string)) {
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+fields:
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		col := columnName(field)
+		if col == "" {
+			continue
+		}
+
+		for _, filter := range filters {
+			if !filter(field, col) {
+				continue fields
+			}
+		}
+
+		fn(i, field, col)
+	}
+}
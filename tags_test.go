@@ -0,0 +1,44 @@
+package db
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestUntaggedFieldsUseSnakeCase(t *testing.T) {
+	type user struct {
+		UserID    int64
+		FirstName string `json:"first"`
+		Skipped   string `db:"-"`
+	}
+
+	want := []string{"user_id", "first"}
+	if got := ColumnNames[user](); !reflect.DeepEqual(got, want) {
+		t.Errorf("ColumnNames = %v, want %v", got, want)
+	}
+
+	// Written and read back under the same name
+	pool := openFakeDB([]string{"user_id", "first"}, []driver.Value{int64(4), "ann"})
+	useSharedPool(t, pool)
+	row, err := One[user]("SELECT * FROM users", nil)
+	if err != nil || row == nil || row.UserID != 4 || row.FirstName != "ann" {
+		t.Errorf("One = %+v, %v, want UserID 4 and FirstName ann", row, err)
+	}
+}
+
+func TestConvertStructToMapRejectsNonStructs(t *testing.T) {
+	type user struct {
+		ID int64
+	}
+
+	if got := ConvertStructToMap((*user)(nil), true); got != nil {
+		t.Errorf("nil pointer = %v, want nil", got)
+	}
+	if got := ConvertStructToMap(42, true); got != nil {
+		t.Errorf("int = %v, want nil", got)
+	}
+	if got := ConvertStructToMap(&user{ID: 3}, true); !reflect.DeepEqual(got, map[string]interface{}{"id": int64(3)}) {
+		t.Errorf("pointer = %v, want id 3", got)
+	}
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Name under which the TLS configuration of WithTLS is registered with the driver.
+const customTLSConfig = "custom"
+
+// Encrypts the connections with config, e.g. for mutual TLS. The server name is taken from
+// the address when config leaves it empty.
+//
+// The configuration is registered with the driver as "custom" so it also appears in formatted DSNs,
+// but each pool keeps its own copy, so different pools may use different configurations.
+func WithTLS(config *tls.Config) DBOption {
+	return func(cfg *mysql.Config) {
+		mysql.RegisterTLSConfig(customTLSConfig, config)
+		cfg.TLSConfig = customTLSConfig
+		cfg.TLS = config.Clone()
+	}
+}
+
+// Builds a WithTLS option from PEM files: the CA certificate verifying the server and the
+// client certificate and key presented to it. The client files may be empty when the server
+// does not require a client certificate.
+func WithTLSFromCertFiles(caCert, clientCert, clientKey string) (DBOption, error) {
+	pem, err := os.ReadFile(caCert)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("db: no certificate found in " + caCert)
+	}
+
+	config := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	if clientCert != "" || clientKey != "" {
+		cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return WithTLS(config), nil
+}
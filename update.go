@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Update runs `UPDATE table SET ... WHERE ...` and returns the number of rows affected.
+//
+// The SET clause holds the non-zero fields of row, or the columns (or field names) listed in cols whatever
+// their value, a nil pointer setting NULL. Columns present in where are never set.
+//
+// where maps column names to values, joined with AND, a nil value matching NULL and a slice any of its
+// elements. An empty where is refused rather than updating every row.
+func Update[T any](table string, row T, where map[string]interface{}, cols ...string) (int64, error) {
+	rv := reflect.Indirect(reflect.ValueOf(row))
+	if rv.Kind() != reflect.Struct {
+		return 0, fmt.Errorf("db: expected a struct, got %T", row)
+	}
+
+	cond, condArgs, err := whereFromMap(where)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		sets []string
+		args []interface{}
+	)
+	eachColumn(rv.Type(), nil, func(i int, field reflect.StructField, col string) {
+		if _, ok := where[col]; ok {
+			return
+		}
+
+		value := rv.Field(i)
+		if len(cols) > 0 {
+			if IndexOf(col, cols) < 0 && IndexOf(field.Name, cols) < 0 {
+				return
+			}
+		} else if value.IsZero() {
+			return
+		}
+
+		sets = append(sets, quoteIdent(col)+" = ?")
+		args = append(args, value.Interface())
+	})
+	if len(sets) == 0 {
+		return 0, fmt.Errorf("db: no columns of %T to update", row)
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", quoteIdent(table), strings.Join(sets, ", "), cond)
+	args = append(args, condArgs...)
+
+	db, err := getPool(false)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := execFrom(context.Background(), db, query, args)
+	if err != nil {
+		return 0, err
+	}
+	publishQueryChange(query, args)
+	return res.RowsAffected()
+}
+
+// Builds a parameterized `col1 = ? AND col2 IS NULL AND col3 IN (?)` clause from where, in column order.
+func whereFromMap(where map[string]interface{}) (string, []interface{}, error) {
+	if len(where) == 0 {
+		return "", nil, errors.New("db: empty where clause")
+	}
+
+	cols := make([]string, 0, len(where))
+	for col := range where {
+		if !identRegex.MatchString(col) {
+			return "", nil, fmt.Errorf("db: invalid identifier %q", col)
+		}
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	var (
+		conds []string
+		args  []interface{}
+	)
+	for _, col := range cols {
+		switch value := where[col]; {
+		case value == nil:
+			conds = append(conds, quoteIdent(col)+" IS NULL")
+		case isSliceArg(value):
+			conds = append(conds, quoteIdent(col)+" IN (?)")
+			args = append(args, value)
+		default:
+			conds = append(conds, quoteIdent(col)+" = ?")
+			args = append(args, value)
+		}
+	}
+	return strings.Join(conds, " AND "), args, nil
+}
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// buildUpsert generates `INSERT INTO table (...) VALUES (...) ON DUPLICATE KEY UPDATE col = VALUES(col), ...`.
+// Without updateCols every column but the primary key is updated, otherwise each of updateCols must be
+// a column of T.
+func buildUpsert[T any](table string, rows []T, updateCols []string) (string, []interface{}, error) {
+	query, args, err := buildInsert("INSERT", table, rows)
+	if err != nil {
+		return "", nil, err
+	}
+
+	t := reflect.TypeOf(rows[0])
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var cols []string
+	eachColumn(t, nil, func(i int, field reflect.StructField, col string) {
+		cols = append(cols, col)
+	})
+
+	if len(updateCols) == 0 {
+		pk, _ := primaryKey(t)
+		for _, col := range cols {
+			if col != pk {
+				updateCols = append(updateCols, col)
+			}
+		}
+	}
+	if len(updateCols) == 0 {
+		return "", nil, fmt.Errorf("db: %s has no columns to update", t)
+	}
+
+	sets := make([]string, len(updateCols))
+	for i, col := range updateCols {
+		if IndexOf(col, cols) < 0 {
+			return "", nil, fmt.Errorf("db: %s has no column %q to update", t, col)
+		}
+		sets[i] = fmt.Sprintf("%s = VALUES(%s)", quoteIdent(col), quoteIdent(col))
+	}
+
+	return query + " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", "), args, nil
+}
+
+// Upsert inserts rows into table with a single INSERT ... ON DUPLICATE KEY UPDATE, updating updateCols
+// (every column but the primary key when empty) of the rows that conflict on a primary or unique key,
+// e.g. for idempotent sync jobs. MySQL counts 1 affected row per insert and 2 per updated row.
+func Upsert[T any](table string, rows []T, updateCols []string) (sql.Result, error) {
+	query, args, err := buildUpsert(table, rows, updateCols)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := getPool(false)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := execFrom(context.Background(), db, query, args)
+	if err == nil {
+		for _, row := range rows {
+			publishChange(table, "UPSERT", row)
+		}
+	}
+	return res, err
+}
+
+// UpsertWithResult inserts row into table, or updates updateCols (every column but the primary key by default)
+// when it conflicts with an existing row, then reads the row back in the same transaction.
+// The returned row carries the values set by the server, such as defaults, auto-increment IDs and trigger changes.
+//
+// The row is read back by its LAST_INSERT_ID(), which an integer primary key sets on update too,
+// or else by the primary key of row.
+func UpsertWithResult[T any](table string, row T, updateCols ...string) (T, error) {
+	var res T
+
+	t := reflect.TypeOf(row)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	pk, ok := primaryKey(t)
+	if !ok {
+		return res, fmt.Errorf("db: %s has no primary key field", t)
+	}
+
+	query, args, err := buildUpsert(table, []T{row}, updateCols)
+	if err != nil {
+		return res, err
+	}
+
+	var pkValue reflect.Value
+	rv := reflect.Indirect(reflect.ValueOf(row))
+	eachColumn(t, nil, func(i int, field reflect.StructField, col string) {
+		if col == pk {
+			pkValue = rv.Field(i)
+		}
+	})
+
+	// LAST_INSERT_ID() is 0 when a conflicting row is updated, unless it is given the key of that row
+	switch pkValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if IndexOf(pk, updateCols) < 0 {
+			query += fmt.Sprintf(", %s = LAST_INSERT_ID(%s)", quoteIdent(pk), quoteIdent(pk))
+		}
+	}
+
+	ctx := context.Background()
+	db, err := getPool(false)
+	if err != nil {
+		return res, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback()
+
+	result, err := execFrom(ctx, tx, query, args)
+	if err != nil {
+		return res, err
+	}
+
+	key := pkValue.Interface()
+	if id, err := result.LastInsertId(); err == nil && id > 0 {
+		key = id
+	}
+
+	res, found, err := oneFrom[T](ctx, tx, fmt.Sprintf("SELECT * FROM %s WHERE %s = ? LIMIT 1", quoteIdent(table), quoteIdent(pk)), []interface{}{key})
+	if err != nil {
+		return res, err
+	}
+	if !found {
+		return res, ErrNoRows
+	}
+
+	if err := tx.Commit(); err != nil {
+		return res, ClassifyError(err)
+	}
+
+	publishChange(table, "UPSERT", res)
+	return res, nil
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+func TestUpsertWithResultSetsLastInsertIDOnUpdate(t *testing.T) {
+	type user struct {
+		ID   int64  `db:"id,pk"`
+		Name string `db:"name"`
+	}
+
+	pool, fake := openFakeConnector([]string{"id", "name"}, []driver.Value{int64(3), "bob"})
+	useSharedPool(t, pool)
+
+	res, err := UpsertWithResult("users", user{Name: "bob"})
+	if err != nil {
+		t.Fatalf("UpsertWithResult error = %v", err)
+	}
+	if res.ID != 3 {
+		t.Errorf("UpsertWithResult = %+v, want ID 3", res)
+	}
+
+	stmts := fake.statements()
+	if len(stmts) == 0 || !strings.HasSuffix(stmts[0], "ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `id` = LAST_INSERT_ID(`id`)") {
+		t.Errorf("upsert statement = %q, want the primary key passed to LAST_INSERT_ID", stmts)
+	}
+}
+
+func TestBuildUpsertPointerRows(t *testing.T) {
+	type user struct {
+		ID   int64  `db:"id,pk"`
+		Name string `db:"name"`
+	}
+
+	query, args, err := buildUpsert("users", []*user{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, nil)
+	if err != nil {
+		t.Fatalf("buildUpsert error = %v", err)
+	}
+	want := "INSERT INTO `users` (`id`, `name`) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)"
+	if query != want || len(args) != 4 {
+		t.Errorf("buildUpsert = %q, %v, want %q", query, args, want)
+	}
+
+	if _, _, err := buildUpsert("users", []*user{{ID: 1}}, []string{"email"}); err == nil {
+		t.Error("buildUpsert accepted an update column that is not a column of the struct")
+	}
+}
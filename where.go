@@ -0,0 +1,43 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Builds a parameterized `col1 = ? AND col2 = ?` clause from the non-zero fields of row.
+//
+// Zero fields are skipped unless their column or field name is listed in includedFields,
+// in which case a nil pointer produces `col IS NULL`. An empty clause means no condition.
+// row may be a pointer to a struct; a nil pointer or a non-struct is an error.
+func WhereFromStruct[T any](row T, includedFields ...string) (clause string, args []interface{}, err error) {
+	rv := reflect.ValueOf(row)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "", nil, fmt.Errorf("db: nil %T", row)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return "", nil, fmt.Errorf("db: %T is not a struct", row)
+	}
+
+	var conds []string
+	eachColumn(rv.Type(), nil, func(i int, field reflect.StructField, col string) {
+		value := rv.Field(i)
+		if value.IsZero() && IndexOf(col, includedFields) < 0 && IndexOf(field.Name, includedFields) < 0 {
+			return
+		}
+
+		if value.Kind() == reflect.Ptr && value.IsNil() {
+			conds = append(conds, quoteIdent(col)+" IS NULL")
+			return
+		}
+
+		conds = append(conds, quoteIdent(col)+" = ?")
+		args = append(args, value.Interface())
+	})
+
+	return strings.Join(conds, " AND "), args, nil
+}
@@ -0,0 +1,24 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWhereFromStruct(t *testing.T) {
+	type filter struct {
+		ID   int64
+		Name string
+	}
+
+	clause, args, err := WhereFromStruct(&filter{ID: 3})
+	if err != nil || clause != "`id` = ?" || !reflect.DeepEqual(args, []interface{}{int64(3)}) {
+		t.Errorf("pointer = %q, %v, %v, want `id` = ? with 3", clause, args, err)
+	}
+	if _, _, err := WhereFromStruct((*filter)(nil)); err == nil {
+		t.Error("nil pointer succeeded, want an error")
+	}
+	if _, _, err := WhereFromStruct(map[string]interface{}{"id": 3}); err == nil {
+		t.Error("map succeeded, want an error")
+	}
+}